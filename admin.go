@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Actions which -admin can do across every Organization on the instance
+var ADMIN_ACTIONS = []string{"cleanup", "echo"}
+
+// A Run found through the Admin API, with where it is
+type adminTarget struct {
+	org string
+	ws  string
+	run *tfe.AdminRun
+}
+
+// Force-cancel the Runs stuck in a status for longer than olderThan, in every Organization on a Terraform Enterprise
+// instance; needs a site admin token
+func (c *Client) AdminCleanup(ctx context.Context, search string, assume bool, stuckStatus tfe.RunStatus, olderThan time.Duration) error {
+	targets, err := c.getAdminRuns(ctx, search, stuckStatus, olderThan)
+	if err != nil {
+		return err
+	}
+
+	// The config restricts force-canceling like canceling
+	actionable := targets[:0]
+	for _, t := range targets {
+		if c.actionableStatus("cancel", t.ws, t.run.ID, t.run.Status) {
+			actionable = append(actionable, t)
+		}
+	}
+	targets = actionable
+
+	orgs := map[string]bool{}
+	for _, t := range targets {
+		orgs[t.org] = true
+		slog.Info("can force-cancel", "organization", t.org, "workspace", t.ws, "runID", t.run.ID, "status", t.run.Status, "age", time.Since(t.run.CreatedAt).Round(time.Second))
+	}
+	slog.Info(fmt.Sprintf("Found %d Run(s) in %d Organization(s)", len(targets), len(orgs)))
+
+	// Site-wide changes need the instance typed out, even with -assume-yes
+	if !confirm(len(targets), assume) || !adminConfirmPrompt(len(targets), len(orgs)) {
+		return nil
+	}
+
+	for _, t := range targets {
+		if err := c.pauser.wait(ctx); err != nil {
+			return err
+		}
+		slog.Info("force-canceling", "organization", t.org, "workspace", t.ws, "runID", t.run.ID)
+		if err := c.Admin.Runs.ForceCancel(ctx, t.run.ID, tfe.AdminRunForceCancelOptions{
+			Comment: tfe.String(fmt.Sprintf("Force-canceled by %s", c.annotation)),
+		}); err != nil {
+			if err := c.tolerate(t.ws, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Log the Runs in a status across every Organization, without changing anything
+func (c *Client) AdminEcho(ctx context.Context, search string, stuckStatus tfe.RunStatus) error {
+	targets, err := c.getAdminRuns(ctx, search, stuckStatus, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		slog.Info("found", "organization", t.org, "workspace", t.ws, "runID", t.run.ID, "status", t.run.Status)
+	}
+	slog.Info(fmt.Sprintf("Found %d Run(s)", len(targets)))
+
+	return nil
+}
+
+// The instance's Runs in a status and created over olderThan ago, search matching the Run, Workspace or Organization
+func (c *Client) getAdminRuns(ctx context.Context, search string, status tfe.RunStatus, olderThan time.Duration) ([]adminTarget, error) {
+	var targets []adminTarget
+
+	n := 0
+	for {
+		opts := &tfe.AdminRunsListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			RunStatus: string(status),
+			Query:     search,
+			Include:   []tfe.AdminRunIncludeOpt{tfe.AdminRunWorkspace, tfe.AdminRunWorkspaceOrg},
+		}
+
+		runList, err := c.Admin.Runs.List(ctx, opts)
+		if err != nil {
+			return targets, err
+		}
+
+		for _, run := range runList.Items {
+			if time.Since(run.CreatedAt) < olderThan {
+				continue
+			}
+
+			t := adminTarget{run: run}
+			if run.Workspace != nil {
+				t.ws = run.Workspace.Name
+				if run.Workspace.Organization != nil {
+					t.org = run.Workspace.Organization.Name
+				}
+			}
+			if t.org == "" && run.Organization != nil {
+				t.org = run.Organization.Name
+			}
+			targets = append(targets, t)
+		}
+
+		if runList.NextPage > n {
+			n = runList.NextPage
+		} else {
+			return targets, nil
+		}
+	}
+}
+
+// Second confirmation for site-wide changes, the instance's hostname has to be typed back
+func adminConfirmPrompt(runCount, orgCount int) bool {
+	host := tfeAddress()
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	fmt.Printf("This will change %d Run(s) across %d Organization(s) on %s. Type the hostname to continue: ", runCount, orgCount, host)
+
+	input, err := readLine()
+	if err != nil || strings.TrimSpace(input) != host {
+		slog.Info("Action(s) aborted")
+		return false
+	}
+
+	return true
+}
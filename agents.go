@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+const agentPollInterval = 15 * time.Second
+
+// Agents in a pool by status
+type agentCounts struct {
+	idle    int
+	busy    int
+	errored int
+}
+
+// Report the agents of every pool the Workspaces run on, failing if any has fewer than the required connected agents
+func (c *Client) agentPreflight(ctx context.Context, workspaces []*tfe.Workspace, requireAgents int) error {
+	pools := map[string]int{}
+	var poolIDs []string
+	for _, ws := range workspaces {
+		if ws.ExecutionMode == "agent" && ws.AgentPoolID != "" {
+			if _, ok := pools[ws.AgentPoolID]; !ok {
+				poolIDs = append(poolIDs, ws.AgentPoolID)
+			}
+			pools[ws.AgentPoolID]++
+		}
+	}
+
+	if len(poolIDs) == 0 || c.skipUnsupported(FeatureAgents, "agent preflight") {
+		return nil
+	}
+
+	var insufficient []string
+	for _, poolID := range poolIDs {
+		pool, err := c.AgentPools.Read(ctx, poolID)
+		if err != nil {
+			return err
+		}
+		counts, err := c.countAgents(ctx, poolID)
+		if err != nil {
+			return err
+		}
+
+		connected := counts.idle + counts.busy
+		slog.Info("agent pool", "agentPool", pool.Name, "connected", connected, "idle", counts.idle, "busy", counts.busy, "errored", counts.errored, "workspaces", pools[poolID])
+		if connected < requireAgents {
+			insufficient = append(insufficient, pool.Name)
+		}
+	}
+
+	if len(insufficient) > 0 {
+		return fmt.Errorf("fewer than %d connected agent(s) in pool(s): %s", requireAgents, strings.Join(insufficient, ", "))
+	}
+
+	return nil
+}
+
+// Create Runs on the agent pool's Workspaces in waves no larger than its idle agents, waiting for each
+// wave to release the agents before starting the next
+func (c *Client) createRunsInWaves(ctx context.Context, poolID string, workspaces []*tfe.Workspace) ([]*tfe.Run, error) {
+	var started []*tfe.Run
+	remaining := workspaces
+	for len(remaining) > 0 {
+		counts, err := c.countAgents(ctx, poolID)
+		if err != nil {
+			return started, err
+		}
+
+		if counts.idle+counts.busy == 0 {
+			for _, ws := range remaining {
+				slog.Warn("skipping, no agents connected", "workspace", ws.Name, "agentPoolID", poolID)
+			}
+			return started, nil
+		}
+
+		if counts.idle == 0 {
+			slog.Info("waiting for idle agents", "agentPoolID", poolID, "busy", counts.busy, "remaining", len(remaining))
+			if err := sleep(ctx, agentPollInterval); err != nil {
+				return started, err
+			}
+			continue
+		}
+
+		wave := remaining[:min(counts.idle, len(remaining))]
+		remaining = remaining[len(wave):]
+		slog.Info("starting wave", "agentPoolID", poolID, "size", len(wave), "idle", counts.idle, "remaining", len(remaining))
+
+		var runs []*tfe.Run
+		for _, ws := range wave {
+			run, err := c.startRun(ctx, ws)
+			if err != nil {
+				return started, err
+			}
+			if run != nil {
+				runs = append(runs, run)
+			}
+		}
+		started = append(started, runs...)
+
+		if len(remaining) > 0 {
+			if _, err := c.waitForRuns(ctx, runs); err != nil {
+				return started, err
+			}
+		}
+	}
+
+	return started, nil
+}
+
+// Poll until none of the Runs are waiting for or holding an agent, returning them as they finished
+func (c *Client) waitForRuns(ctx context.Context, runs []*tfe.Run) ([]*tfe.Run, error) {
+	var finished []*tfe.Run
+	pending := runs
+	for {
+		var next []*tfe.Run
+		for _, run := range pending {
+			current, err := c.Runs.Read(ctx, run.ID)
+			if err != nil {
+				if err := c.tolerate(run.ID, err); err != nil {
+					return finished, err
+				}
+				continue
+			}
+
+			if current.Status == tfe.RunPending || slices.Contains(IN_FLIGHT_STATUSES, current.Status) {
+				next = append(next, current)
+			} else {
+				finished = append(finished, current)
+			}
+		}
+
+		if len(next) == 0 {
+			return finished, nil
+		}
+
+		slog.Info(fmt.Sprintf("Waiting for %d Run(s) in flight", len(next)))
+		pending = next
+
+		if err := sleep(ctx, agentPollInterval); err != nil {
+			return finished, err
+		}
+	}
+}
+
+func (c *Client) countAgents(ctx context.Context, poolID string) (agentCounts, error) {
+	counts := agentCounts{}
+
+	agents, err := c.getAgents(ctx, poolID)
+	if err != nil {
+		return counts, err
+	}
+
+	for _, agent := range agents {
+		switch agent.Status {
+		case "idle":
+			counts.idle++
+		case "busy":
+			counts.busy++
+		case "errored":
+			counts.errored++
+		}
+	}
+
+	return counts, nil
+}
+
+func (c *Client) getAgents(ctx context.Context, poolID string) ([]*tfe.Agent, error) {
+	var agents []*tfe.Agent
+
+	n := 0
+	for {
+		opts := &tfe.AgentListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+		}
+
+		agentList, err := c.Agents.List(ctx, poolID, opts)
+		if err != nil {
+			return agents, err
+		}
+
+		agents = append(agents, agentList.Items...)
+
+		if agentList.NextPage > n {
+			n = agentList.NextPage
+		} else {
+			return agents, nil
+		}
+	}
+}
+
+// Wait for the duration unless the context is canceled first
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
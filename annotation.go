@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Set at build time with -ldflags "-X main.VERSION=..."
+var VERSION = "dev"
+
+// Marks the Runs this tool creates or confirms, so they stand out from human actions in the Run history
+func newAnnotation(batchID string) string {
+	parts := []string{"go-tfe-bulk", VERSION}
+	if batchID != "" {
+		parts = append(parts, fmt.Sprintf("batch=%s", batchID))
+	}
+	if operator := operatorName(); operator != "" {
+		parts = append(parts, fmt.Sprintf("operator=%s", operator))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, " "))
+}
+
+// Identifies an invocation given no -batch-id, e.g. 20240102T150405-9f86d081
+func newBatchID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(b))
+}
+
+// The batch the Run was queued by, from its message, or empty if this tool didn't queue it
+func runBatchID(run *tfe.Run) string {
+	if !isOwnRun(run) {
+		return ""
+	}
+	annotation, _, _ := strings.Cut(strings.TrimPrefix(run.Message, "Queued by ["), "]")
+	for _, part := range strings.Fields(annotation) {
+		if id, ok := strings.CutPrefix(part, "batch="); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// Who is running the tool, as opposed to who owns the token
+func operatorName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Whether the Run was queued by this tool, in this or any earlier batch
+func isOwnRun(run *tfe.Run) bool {
+	return strings.HasPrefix(run.Message, "Queued by [go-tfe-bulk ")
+}
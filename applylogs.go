@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Colour codes in the logs, which only get in the way outside a terminal
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Collect the last lines of the logs of every apply which failed within since on the Workspace(s), or of the -run-ids
+// Runs, newest first, into one file per Workspace in dir for post-incident review
+func (c *Client) ApplyLogs(ctx context.Context, org, search string, since time.Duration, tailLines int, dir string) error {
+	if tailLines <= 0 {
+		return fmt.Errorf("-tail-lines must be positive for apply-logs")
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	// Each -run-ids Run stands in for its Workspace's current Run, so a Workspace is listed once per Run given
+	given := map[string][]*tfe.Run{}
+	if len(c.runIDs) > 0 {
+		var unique []*tfe.Workspace
+		for _, ws := range workspaces {
+			if _, ok := given[ws.ID]; !ok {
+				unique = append(unique, ws)
+			}
+			given[ws.ID] = append(given[ws.ID], ws.CurrentRun)
+		}
+		workspaces = unique
+	}
+
+	from := time.Now().Add(-since)
+	collected, failed := 0, 0
+	for _, ws := range workspaces {
+		runs, ok := given[ws.ID]
+		if !ok {
+			if runs, err = c.getRunsSince(ctx, ws.ID, from); err != nil {
+				if err := c.tolerate(ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		var b strings.Builder
+		found := 0
+		for _, run := range runs {
+			if !applyFailed(run) {
+				continue
+			}
+			found++
+
+			tail, err := c.getApplyLogTail(ctx, run.Apply.ID, tailLines)
+			if err != nil {
+				if err := c.tolerate(ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			var erroredAt time.Time
+			if run.StatusTimestamps != nil {
+				erroredAt = run.StatusTimestamps.ErroredAt
+			}
+			fmt.Fprintf(&b, "==> %s errored at %s: %s\n", run.ID, erroredAt.Format(time.RFC3339), run.Message)
+			for _, line := range tail {
+				fmt.Fprintln(&b, line)
+			}
+			fmt.Fprintln(&b)
+		}
+		if found == 0 {
+			continue
+		}
+
+		path := filepath.Join(dir, ws.Name+".log")
+		slog.Info("collecting apply logs", "workspace", ws.Name, "failed", found, "path", path)
+		if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+			return err
+		}
+		collected++
+		failed += found
+	}
+
+	slog.Info(fmt.Sprintf("Collected the logs of %d failed apply(s) from %d of %d Workspace(s) to %s", failed, collected, len(workspaces), dir))
+	return nil
+}
+
+// Whether the Run errored once it had started applying, rather than while planning
+func applyFailed(run *tfe.Run) bool {
+	return run.Status == tfe.RunErrored && run.Apply != nil && run.StatusTimestamps != nil && !run.StatusTimestamps.ApplyingAt.IsZero()
+}
+
+// The last lines of the apply's log, without colour codes
+func (c *Client) getApplyLogTail(ctx context.Context, applyID string, lines int) ([]string, error) {
+	logs, err := c.Applies.Logs(ctx, applyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tail []string
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		tail = append(tail, ansiEscape.ReplaceAllString(scanner.Text(), ""))
+		if len(tail) > lines {
+			tail = tail[1:]
+		}
+	}
+	return tail, scanner.Err()
+}
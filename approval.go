@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// How often -approval-watch checks the Runs still waiting for an approval comment
+const approvalPollInterval = time.Minute
+
+func newApprovalCheck(marker string) *Check {
+	return &Check{Name: "approval comment", ApprovalMarker: marker}
+}
+
+// Whether a comment on the Run, posted by a human or a ticket bot, contains the marker
+func (c *Client) hasApprovalComment(ctx context.Context, run *tfe.Run, marker string) (bool, error) {
+	list, err := c.Comments.List(ctx, run.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, comment := range list.Items {
+		if strings.Contains(comment.Body, marker) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Confirm the Runs approved so far, then keep confirming the rest as their approval comments are posted, until
+// none are left waiting or the watch times out
+func (c *Client) WatchApprovals(ctx context.Context, assume bool, watch time.Duration, confirmApproved func() error) error {
+	if c.checklist == nil || !c.checklist.requiresApproval() {
+		return errors.New("-approval-watch requires -approval-marker or an approvalMarker condition")
+	}
+	if !assume {
+		return errors.New("-approval-watch confirms Runs as they're approved, so requires -assume-yes")
+	}
+
+	deadline := time.Now().Add(watch)
+	for {
+		c.checklist.unapproved = 0
+		if err := confirmApproved(); err != nil {
+			return err
+		}
+		if c.checklist.unapproved == 0 {
+			return nil
+		}
+		if time.Now().Add(approvalPollInterval).After(deadline) {
+			slog.Warn(fmt.Sprintf("Stopped watching, %d Run(s) still waiting for approval", c.checklist.unapproved))
+			return nil
+		}
+
+		slog.Info(fmt.Sprintf("Waiting for approval of %d Run(s)", c.checklist.unapproved), "next", approvalPollInterval)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(approvalPollInterval):
+		}
+	}
+}
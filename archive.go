@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+const ARCHIVED_TAG = "archived"
+
+// Starts the metadata archive appends to the description, so the settings it changed can be restored
+const archiveMarker = "go-tfe-bulk archive: "
+
+// File triggers on a path no commit touches, so VCS pushes stop queueing Runs
+const archivedTriggerPattern = "/.go-tfe-bulk-archived/**"
+
+// What archiving changed on a Workspace
+type ArchiveMetadata struct {
+	ArchivedAt time.Time `json:"archivedAt"`
+	ArchivedBy string    `json:"archivedBy"`
+	// Already locked by someone else, so not locked by archive
+	WasLocked bool `json:"wasLocked"`
+	AutoApply bool `json:"autoApply"`
+	// The VCS trigger settings overridden, nil if the Workspace isn't VCS-driven
+	Triggers *ArchivedTriggers `json:"triggers,omitempty"`
+}
+
+type ArchivedTriggers struct {
+	FileTriggersEnabled bool     `json:"fileTriggersEnabled"`
+	TriggerPatterns     []string `json:"triggerPatterns"`
+	TriggerPrefixes     []string `json:"triggerPrefixes"`
+	SpeculativeEnabled  bool     `json:"speculativeEnabled"`
+}
+
+// go-tfe omits empty trigger lists from updates, so settings which may need clearing are sent through the raw API
+type archiveUpdateOptions struct {
+	Type                string   `jsonapi:"primary,workspaces"`
+	AutoApply           bool     `jsonapi:"attr,auto-apply"`
+	Description         string   `jsonapi:"attr,description"`
+	FileTriggersEnabled *bool    `jsonapi:"attr,file-triggers-enabled,omitempty"`
+	TriggerPatterns     []string `jsonapi:"attr,trigger-patterns"`
+	TriggerPrefixes     []string `jsonapi:"attr,trigger-prefixes"`
+	SpeculativeEnabled  *bool    `jsonapi:"attr,speculative-enabled,omitempty"`
+}
+
+// Soft-retire the Workspace(s): disable auto-apply and VCS triggers, tag them archived and lock them, recording what
+// was changed in the description
+func (c *Client) Archive(ctx context.Context, org, search string, assume bool) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var archiveList []*tfe.Workspace
+	for _, ws := range workspaces {
+		if _, archived := archiveMetadata(ws); archived {
+			slog.Info("skipping, already archived", "workspace", ws.Name)
+			continue
+		}
+		if !ws.Permissions.CanUpdate || (!ws.Locked && !ws.Permissions.CanLock) {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		slog.Info("can archive", "workspace", ws.Name, "locked", ws.Locked, "autoApply", ws.AutoApply, "vcs", ws.VCSRepo != nil)
+		archiveList = append(archiveList, ws)
+	}
+
+	if confirm(len(archiveList), assume) {
+		for _, ws := range archiveList {
+			if err := c.archiveWorkspace(ctx, ws); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) archiveWorkspace(ctx context.Context, ws *tfe.Workspace) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+
+	meta := ArchiveMetadata{
+		ArchivedAt: time.Now().UTC(),
+		ArchivedBy: c.annotation,
+		WasLocked:  ws.Locked,
+		AutoApply:  ws.AutoApply,
+	}
+	opts := &archiveUpdateOptions{
+		AutoApply: false,
+		// Keep the trigger lists as they are unless they're overridden below
+		TriggerPatterns: nonNil(ws.TriggerPatterns),
+		TriggerPrefixes: nonNil(ws.TriggerPrefixes),
+	}
+	if ws.VCSRepo != nil {
+		meta.Triggers = &ArchivedTriggers{
+			FileTriggersEnabled: ws.FileTriggersEnabled,
+			TriggerPatterns:     nonNil(ws.TriggerPatterns),
+			TriggerPrefixes:     nonNil(ws.TriggerPrefixes),
+			SpeculativeEnabled:  ws.SpeculativeEnabled,
+		}
+		opts.FileTriggersEnabled = tfe.Bool(true)
+		opts.TriggerPatterns = []string{archivedTriggerPattern}
+		opts.TriggerPrefixes = []string{}
+		opts.SpeculativeEnabled = tfe.Bool(false)
+	}
+
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	opts.Description = archiveMarker + string(b)
+	if ws.Description != "" {
+		opts.Description = ws.Description + "\n\n" + opts.Description
+	}
+
+	slog.Info("archiving", "workspace", ws.Name)
+	if err := c.updateArchiveSettings(ctx, ws, opts); err != nil {
+		return c.tolerate(ws.Name, err)
+	}
+
+	if err := c.Workspaces.AddTags(ctx, ws.ID, tfe.WorkspaceAddTagsOptions{
+		Tags: []*tfe.Tag{{Name: ARCHIVED_TAG}},
+	}); err != nil {
+		return c.tolerate(ws.Name, err)
+	}
+
+	if !ws.Locked {
+		if _, err := c.Workspaces.Lock(ctx, ws.ID, tfe.WorkspaceLockOptions{
+			Reason: tfe.String(fmt.Sprintf("Archived by %s", c.annotation)),
+		}); err != nil {
+			return c.tolerate(ws.Name, err)
+		}
+	}
+
+	c.acted(ctx, "archive", ws, nil)
+	return nil
+}
+
+// Reverse archive on the Workspace(s) it recorded metadata on: restore auto-apply and VCS triggers, remove the tag
+// and unlock them
+func (c *Client) Unarchive(ctx context.Context, org, search string, assume bool) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var restoreList []*tfe.Workspace
+	for _, ws := range workspaces {
+		meta, archived := archiveMetadata(ws)
+		if !archived {
+			continue
+		}
+		if meta == nil {
+			slog.Warn("skipping, archive metadata unreadable", "workspace", ws.Name)
+			continue
+		}
+		if !ws.Permissions.CanUpdate || (ws.Locked && !meta.WasLocked && !ws.Permissions.CanUnlock) {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		slog.Info("can unarchive", "workspace", ws.Name, "archivedAt", meta.ArchivedAt, "archivedBy", meta.ArchivedBy)
+		restoreList = append(restoreList, ws)
+	}
+
+	if confirm(len(restoreList), assume) {
+		for _, ws := range restoreList {
+			if err := c.unarchiveWorkspace(ctx, ws); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) unarchiveWorkspace(ctx context.Context, ws *tfe.Workspace) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+
+	meta, _ := archiveMetadata(ws)
+	description, _, _ := strings.Cut(ws.Description, archiveMarker)
+	opts := &archiveUpdateOptions{
+		AutoApply:       meta.AutoApply,
+		Description:     strings.TrimSuffix(description, "\n\n"),
+		TriggerPatterns: nonNil(ws.TriggerPatterns),
+		TriggerPrefixes: nonNil(ws.TriggerPrefixes),
+	}
+	if meta.Triggers != nil {
+		opts.FileTriggersEnabled = tfe.Bool(meta.Triggers.FileTriggersEnabled)
+		opts.TriggerPatterns = nonNil(meta.Triggers.TriggerPatterns)
+		opts.TriggerPrefixes = nonNil(meta.Triggers.TriggerPrefixes)
+		opts.SpeculativeEnabled = tfe.Bool(meta.Triggers.SpeculativeEnabled)
+	}
+
+	// The description is restored last, so whatever fails part way through can be retried from the metadata
+	if ws.Locked && !meta.WasLocked {
+		slog.Info("unlocking", "workspace", ws.Name)
+		if _, err := c.Workspaces.Unlock(ctx, ws.ID); err != nil {
+			return c.tolerate(ws.Name, err)
+		}
+	}
+
+	if err := c.Workspaces.RemoveTags(ctx, ws.ID, tfe.WorkspaceRemoveTagsOptions{
+		Tags: []*tfe.Tag{{Name: ARCHIVED_TAG}},
+	}); err != nil {
+		return c.tolerate(ws.Name, err)
+	}
+
+	slog.Info("restoring", "workspace", ws.Name, "autoApply", meta.AutoApply)
+	if err := c.updateArchiveSettings(ctx, ws, opts); err != nil {
+		return c.tolerate(ws.Name, err)
+	}
+
+	c.acted(ctx, "unarchive", ws, nil)
+	return nil
+}
+
+func (c *Client) updateArchiveSettings(ctx context.Context, ws *tfe.Workspace, opts *archiveUpdateOptions) error {
+	req, err := c.NewRequest("PATCH", fmt.Sprintf("workspaces/%s", url.PathEscape(ws.ID)), opts)
+	if err != nil {
+		return err
+	}
+	return req.Do(ctx, &tfe.Workspace{})
+}
+
+// The metadata archive recorded in the Workspace's description, and whether there is any
+func archiveMetadata(ws *tfe.Workspace) (*ArchiveMetadata, bool) {
+	_, recorded, found := strings.Cut(ws.Description, archiveMarker)
+	if !found {
+		return nil, false
+	}
+
+	meta := &ArchiveMetadata{}
+	if err := json.Unmarshal([]byte(recorded), meta); err != nil {
+		slog.Warn("unable to read archive metadata", "workspace", ws.Name, "error", err)
+		return nil, true
+	}
+	return meta, true
+}
+
+// Lists the API should clear rather than leave alone, sent as [] instead of null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
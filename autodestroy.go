@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Clears an auto-destroy flag
+const autoDestroyOff = "off"
+
+// Attributes to set from -destroy-at (a time, or a duration from now) and -destroy-inactive (e.g. "14d"),
+// either of which can be "off" to clear it
+func parseAutoDestroy(at, inactive string, now time.Time) (map[string]any, error) {
+	attrs := map[string]any{}
+
+	switch at {
+	case "":
+	case autoDestroyOff:
+		attrs["auto-destroy-at"] = nil
+	default:
+		when, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			d, durationErr := time.ParseDuration(at)
+			if durationErr != nil || d <= 0 {
+				return nil, fmt.Errorf("-destroy-at: expected a time like 2024-01-31T18:00:00Z or a duration like 72h, got %q", at)
+			}
+			when = now.Add(d)
+		}
+		if !when.After(now) {
+			return nil, fmt.Errorf("-destroy-at: %s is in the past", when.Format(time.RFC3339))
+		}
+		attrs["auto-destroy-at"] = when.UTC().Format(time.RFC3339)
+	}
+
+	switch inactive {
+	case "":
+	case autoDestroyOff:
+		attrs["auto-destroy-activity-duration"] = nil
+	default:
+		if !settingDurationPattern.MatchString(inactive) {
+			return nil, fmt.Errorf("-destroy-inactive: expected a number of days or hours like 14d or 36h, got %q", inactive)
+		}
+		attrs["auto-destroy-activity-duration"] = inactive
+	}
+
+	return attrs, nil
+}
+
+// Schedule (or clear) auto-destroy on the Workspace(s), at a time and/or after a period without activity
+func (c *Client) AutoDestroy(ctx context.Context, org, search string, assume bool, at, inactive string) error {
+	attrs, err := parseAutoDestroy(strings.TrimSpace(at), strings.TrimSpace(inactive), time.Now())
+	if err != nil {
+		return err
+	}
+	if len(attrs) == 0 {
+		return fmt.Errorf("-destroy-at or -destroy-inactive is required for auto-destroy")
+	}
+	return c.changeAttributes(ctx, org, search, assume, "auto-destroy", attrs)
+}
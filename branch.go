@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Flag VCS-driven Workspace(s) whose tracked branch is failing to ingress or has stopped receiving commits
+func (c *Client) BranchCheck(ctx context.Context, org, search string, staleAfter time.Duration) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	flagged := 0
+	cutoff := time.Now().Add(-staleAfter)
+	for _, ws := range workspaces {
+		if ws.VCSRepo == nil {
+			continue
+		}
+
+		branch := ws.VCSRepo.Branch
+		if branch == "" {
+			branch = "(default)"
+		}
+
+		cvs, err := c.getRecentConfigVersions(ctx, ws.ID)
+		if err != nil {
+			return err
+		}
+
+		latest := latestIngress(cvs)
+
+		switch {
+		case latest == nil:
+			slog.Warn("no ingressed commits", "workspace", ws.Name, "repo", ws.VCSRepo.Identifier, "branch", branch)
+			flagged++
+		case latest.Status == tfe.ConfigurationErrored:
+			slog.Warn("ingress failing", "workspace", ws.Name, "repo", ws.VCSRepo.Identifier, "branch", branch, "error", latest.ErrorMessage)
+			flagged++
+		case ingressedAt(latest).Before(cutoff):
+			slog.Warn("no recent commits", "workspace", ws.Name, "repo", ws.VCSRepo.Identifier, "branch", branch,
+				"lastCommit", commitSHA(latest), "lastIngress", ingressedAt(latest).Format(time.RFC3339))
+			flagged++
+		default:
+			slog.Info("ok", "workspace", ws.Name, "repo", ws.VCSRepo.Identifier, "branch", branch, "lastCommit", commitSHA(latest))
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Flagged %d Workspace(s)", flagged))
+
+	return nil
+}
+
+// The newest Configuration Version ingressed from VCS, or nil if there are none
+func latestIngress(cvs []*tfe.ConfigurationVersion) *tfe.ConfigurationVersion {
+	// Errored ingresses may have no ingress attributes, so go by the source instead
+	for _, cv := range cvs {
+		if !cv.Speculative && cv.Source != tfe.ConfigurationSourceAPI && cv.Source != tfe.ConfigurationSourceTerraform {
+			return cv
+		}
+	}
+	return nil
+}
+
+// Why a Run on the VCS-driven Workspace would fail fetching its configuration, or "" if its latest ingress succeeded
+func (c *Client) ingressError(ctx context.Context, ws *tfe.Workspace) (string, error) {
+	if ws.VCSRepo == nil {
+		return "", nil
+	}
+
+	cvs, err := c.getRecentConfigVersions(ctx, ws.ID)
+	if err != nil {
+		return "", err
+	}
+
+	latest := latestIngress(cvs)
+	switch {
+	case latest == nil:
+		return "no ingressed commits", nil
+	case latest.Status == tfe.ConfigurationErrored:
+		if latest.ErrorMessage != "" {
+			return latest.ErrorMessage, nil
+		}
+		return "ingress errored", nil
+	}
+	return "", nil
+}
+
+// When the Configuration Version was ingressed, falling back through the earlier timestamps
+func ingressedAt(cv *tfe.ConfigurationVersion) time.Time {
+	if cv.StatusTimestamps == nil {
+		return time.Time{}
+	}
+	for _, t := range []time.Time{cv.StatusTimestamps.FinishedAt, cv.StatusTimestamps.StartedAt, cv.StatusTimestamps.QueuedAt} {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func commitSHA(cv *tfe.ConfigurationVersion) string {
+	if cv.IngressAttributes == nil {
+		return ""
+	}
+	return cv.IngressAttributes.CommitSHA
+}
+
+// The first page of Configuration Versions, newest first, with their ingress attributes
+func (c *Client) getRecentConfigVersions(ctx context.Context, workspaceID string) ([]*tfe.ConfigurationVersion, error) {
+	opts := &tfe.ConfigurationVersionListOptions{
+		Include: []tfe.ConfigVerIncludeOpt{
+			tfe.ConfigVerIngressAttributes,
+		},
+	}
+
+	cvList, err := c.ConfigurationVersions.List(ctx, workspaceID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return cvList.Items, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Named conditions every Run must meet before it's confirmed
+type Checklist struct {
+	checks []*Check
+	// Every Run evaluated, for the report
+	evaluated []RunChecklist
+	// Runs evaluated without an approval comment, since -approval-watch last checked
+	unapproved int
+}
+
+// One condition, exactly one of the fields after Name (other than Query) is set
+type Check struct {
+	Name string `json:"name"`
+	// The plan destroys nothing
+	NoDestroys bool `json:"noDestroys,omitempty"`
+	// The cost estimate's monthly delta is at most this
+	MaxCostDelta *float64 `json:"maxCostDelta,omitempty"`
+	// Every policy check passed or was overridden
+	PoliciesPassed bool `json:"policiesPassed,omitempty"`
+	// The Run was created at most this long ago, e.g. "24h"
+	MaxRunAge string `json:"maxRunAge,omitempty"`
+	// A Rego policy file, evaluated against the plan JSON with opa, denies nothing
+	PlanPolicy string `json:"planPolicy,omitempty"`
+	// The policy's rule producing denials, defaults to data.terraform.deny
+	Query string `json:"query,omitempty"`
+	// A comment on the Run contains this, e.g. "/approve CHG-1234"
+	ApprovalMarker string `json:"approvalMarker,omitempty"`
+
+	maxRunAge time.Duration
+}
+
+type CheckResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+type RunChecklist struct {
+	Workspace string        `json:"workspace"`
+	RunID     string        `json:"runID"`
+	Passed    bool          `json:"passed"`
+	Results   []CheckResult `json:"results"`
+}
+
+// Load a JSON list of Checks
+func openChecklist(path string) (*Checklist, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cl := &Checklist{}
+	if err := json.Unmarshal(b, &cl.checks); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for idx, check := range cl.checks {
+		if check.Name == "" {
+			check.Name = fmt.Sprintf("check %d", idx)
+		}
+
+		set := 0
+		for _, isSet := range []bool{check.NoDestroys, check.MaxCostDelta != nil, check.PoliciesPassed, check.MaxRunAge != "", check.PlanPolicy != "", check.ApprovalMarker != ""} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("%s: %s: expected exactly one of noDestroys, maxCostDelta, policiesPassed, maxRunAge, planPolicy or approvalMarker", path, check.Name)
+		}
+
+		if check.MaxRunAge != "" {
+			if check.maxRunAge, err = time.ParseDuration(check.MaxRunAge); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, check.Name, err)
+			}
+		}
+		if check.PlanPolicy != "" {
+			if err := check.parsePlanPolicy(); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, check.Name, err)
+			}
+		}
+	}
+
+	return cl, nil
+}
+
+// Evaluate the -checklist on the Run, logging each result; true without a checklist
+func (c *Client) passesChecklist(ctx context.Context, ws *tfe.Workspace, run *tfe.Run) (bool, error) {
+	if c.checklist == nil {
+		return true, nil
+	}
+
+	run, err := c.Runs.ReadWithOptions(ctx, run.ID, &tfe.RunReadOptions{
+		Include: []tfe.RunIncludeOpt{tfe.RunPlan, tfe.RunCostEstimate},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	evaluated := RunChecklist{Workspace: ws.Name, RunID: run.ID, Passed: true}
+	for _, check := range c.checklist.checks {
+		result, err := c.evaluateCheck(ctx, check, run)
+		if err != nil {
+			return false, err
+		}
+
+		slog.Info("check", "workspace", ws.Name, "runID", run.ID, "check", result.Name, "passed", result.Passed, "detail", result.Detail)
+		evaluated.Results = append(evaluated.Results, result)
+		evaluated.Passed = evaluated.Passed && result.Passed
+	}
+	c.checklist.evaluated = append(c.checklist.evaluated, evaluated)
+
+	return evaluated.Passed, nil
+}
+
+// Conditions which can't be evaluated, e.g. no cost estimate, fail rather than let the Run through
+func (c *Client) evaluateCheck(ctx context.Context, check *Check, run *tfe.Run) (CheckResult, error) {
+	result := CheckResult{Name: check.Name}
+
+	switch {
+	case check.NoDestroys:
+		if run.Plan == nil {
+			result.Detail = "no plan"
+			break
+		}
+		result.Passed = run.Plan.ResourceDestructions == 0
+		result.Detail = fmt.Sprintf("%d destroyed", run.Plan.ResourceDestructions)
+
+	case check.MaxCostDelta != nil:
+		if c.skipUnsupported(FeatureCostEstimation, "cost checks") || run.CostEstimate == nil || run.CostEstimate.Status != tfe.CostEstimateFinished {
+			result.Detail = "no cost estimate"
+			break
+		}
+		delta, err := strconv.ParseFloat(run.CostEstimate.DeltaMonthlyCost, 64)
+		if err != nil {
+			result.Detail = fmt.Sprintf("unreadable delta %q", run.CostEstimate.DeltaMonthlyCost)
+			break
+		}
+		result.Passed = delta <= *check.MaxCostDelta
+		result.Detail = fmt.Sprintf("delta %s/month, max %.2f", run.CostEstimate.DeltaMonthlyCost, *check.MaxCostDelta)
+
+	case check.PoliciesPassed:
+		if c.skipUnsupported(FeaturePolicyChecks, "policy checks") {
+			result.Detail = "no policy checks"
+			break
+		}
+		pcList, err := c.PolicyChecks.List(ctx, run.ID, nil)
+		if err != nil {
+			return result, err
+		}
+		var failed []string
+		for _, pc := range pcList.Items {
+			if pc.Status != tfe.PolicyPasses && pc.Status != tfe.PolicyOverridden {
+				failed = append(failed, string(pc.Status))
+			}
+		}
+		result.Passed = len(failed) == 0
+		result.Detail = fmt.Sprintf("%d policy check(s)", len(pcList.Items))
+		if len(failed) > 0 {
+			result.Detail += ", " + strings.Join(failed, ", ")
+		}
+
+	case check.MaxRunAge != "":
+		age := time.Since(run.CreatedAt).Round(time.Second)
+		result.Passed = age <= check.maxRunAge
+		result.Detail = fmt.Sprintf("created %s ago, max %s", age, check.maxRunAge)
+
+	case check.PlanPolicy != "":
+		return c.evaluatePlanPolicy(ctx, check, run)
+
+	case check.ApprovalMarker != "":
+		approved, err := c.hasApprovalComment(ctx, run, check.ApprovalMarker)
+		if err != nil {
+			return result, err
+		}
+		result.Passed = approved
+		result.Detail = fmt.Sprintf("no comment with %q", check.ApprovalMarker)
+		if approved {
+			result.Detail = "approved by comment"
+		} else {
+			c.checklist.unapproved++
+		}
+	}
+
+	return result, nil
+}
+
+// Whether any condition is an approval comment
+func (cl *Checklist) requiresApproval() bool {
+	for _, check := range cl.checks {
+		if check.ApprovalMarker != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Write every Run the checklist was evaluated on, if there's a checklist and a report file
+func (c *Client) writeChecklistReport(path string) error {
+	if c.checklist == nil || path == "" {
+		return nil
+	}
+	return c.writeJSONReport(path, struct {
+		BatchID string         `json:"batchID"`
+		Runs    []RunChecklist `json:"runs"`
+	}{c.batchID, c.checklist.evaluated})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Post a comment on the CurrentRun, e.g. why the fleet is paused, so it's visible to anyone looking at the Runs
+func (c *Client) Comment(ctx context.Context, org, search string, assume bool, body string) error {
+	if body == "" {
+		return fmt.Errorf("-comment-body is required for comment")
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var commentList []target
+	for _, ws := range workspaces {
+		slog.Info("can comment", "workspace", ws.Name, "runID", ws.CurrentRun.ID, "status", ws.CurrentRun.Status)
+		commentList = append(commentList, target{ws, ws.CurrentRun})
+	}
+
+	if confirm(len(commentList), assume) {
+		for _, t := range commentList {
+			if err := c.pauser.wait(ctx); err != nil {
+				return err
+			}
+
+			slog.Info("commenting", "workspace", t.ws.Name, "runID", t.run.ID)
+			if _, err := c.Comments.Create(ctx, t.run.ID, tfe.CommentCreateOptions{
+				Body: fmt.Sprintf("%s\n\n(%s)", body, c.annotation),
+			}); err != nil {
+				if err := c.tolerate(t.ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			c.acted(ctx, "comment", t.ws, t.run)
+		}
+	}
+
+	return nil
+}
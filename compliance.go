@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Point-in-time evidence of how the Workspace(s) are configured and who can change them
+type ComplianceExport struct {
+	Organization string                 `json:"organization"`
+	Search       string                 `json:"search,omitempty"`
+	GeneratedAt  time.Time              `json:"generatedAt"`
+	Workspaces   []*ComplianceWorkspace `json:"workspaces"`
+}
+
+type ComplianceWorkspace struct {
+	ID               string              `json:"id"`
+	Name             string              `json:"name"`
+	ExecutionMode    string              `json:"executionMode"`
+	AutoApply        bool                `json:"autoApply"`
+	AllowDestroyPlan bool                `json:"allowDestroyPlan"`
+	Locked           bool                `json:"locked"`
+	TerraformVersion string              `json:"terraformVersion"`
+	VCSRepo          string              `json:"vcsRepo,omitempty"`
+	VCSBranch        string              `json:"vcsBranch,omitempty"`
+	Tags             []string            `json:"tags"`
+	TeamAccess       []*ComplianceAccess `json:"teamAccess"`
+	PolicySets       []string            `json:"policySets"`
+	LastApply        *ComplianceApply    `json:"lastApply"`
+}
+
+type ComplianceAccess struct {
+	Team   string `json:"team"`
+	Access string `json:"access"`
+}
+
+type ComplianceApply struct {
+	RunID     string    `json:"runID"`
+	AppliedAt time.Time `json:"appliedAt"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	Message   string    `json:"message"`
+}
+
+var COMPLIANCE_CSV_HEADER = []string{
+	"id", "name", "execution_mode", "auto_apply", "allow_destroy_plan", "locked", "terraform_version",
+	"vcs_repo", "vcs_branch", "tags", "team_access", "policy_sets", "last_apply_run_id", "last_applied_at", "last_apply_created_by",
+}
+
+// Export the settings, team access, policy sets and last apply of the Workspace(s) as JSON or CSV
+func (c *Client) ExportCompliance(ctx context.Context, org, search, format, reportFile string) error {
+	if format != "json" && format != "csv" {
+		return fmt.Errorf("unsupported format %q, expected json or csv", format)
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	teams, err := c.getTeams(ctx, org)
+	if err != nil {
+		return err
+	}
+	teamNames := make(map[string]string, len(teams))
+	for _, team := range teams {
+		teamNames[team.ID] = team.Name
+	}
+
+	var policySets []*tfe.PolicySet
+	if !c.skipUnsupported(FeaturePolicyChecks, "policy sets") {
+		if policySets, err = c.getPolicySets(ctx, org); err != nil {
+			return err
+		}
+	}
+
+	export := &ComplianceExport{
+		Organization: org,
+		Search:       search,
+		GeneratedAt:  time.Now().UTC(),
+	}
+
+	for _, ws := range workspaces {
+		cw := &ComplianceWorkspace{
+			ID:               ws.ID,
+			Name:             ws.Name,
+			ExecutionMode:    ws.ExecutionMode,
+			AutoApply:        ws.AutoApply,
+			AllowDestroyPlan: ws.AllowDestroyPlan,
+			Locked:           ws.Locked,
+			TerraformVersion: ws.TerraformVersion,
+			Tags:             append([]string{}, ws.TagNames...),
+			TeamAccess:       []*ComplianceAccess{},
+			PolicySets:       []string{},
+		}
+		if ws.VCSRepo != nil {
+			cw.VCSRepo = ws.VCSRepo.Identifier
+			cw.VCSBranch = ws.VCSRepo.Branch
+		}
+		sort.Strings(cw.Tags)
+
+		access, err := c.getTeamAccess(ctx, ws.ID)
+		if err != nil {
+			return err
+		}
+		for _, ta := range access {
+			cw.TeamAccess = append(cw.TeamAccess, &ComplianceAccess{
+				Team:   teamNames[ta.Team.ID],
+				Access: string(ta.Access),
+			})
+		}
+		sort.Slice(cw.TeamAccess, func(i, j int) bool { return cw.TeamAccess[i].Team < cw.TeamAccess[j].Team })
+
+		for _, ps := range policySets {
+			if ps.Global || containsWorkspace(ps.Workspaces, ws.ID) {
+				cw.PolicySets = append(cw.PolicySets, ps.Name)
+			}
+		}
+		sort.Strings(cw.PolicySets)
+
+		run, err := c.getLastApply(ctx, ws.ID)
+		if err != nil {
+			return err
+		}
+		if run != nil {
+			cw.LastApply = &ComplianceApply{
+				RunID:   run.ID,
+				Message: run.Message,
+			}
+			if run.StatusTimestamps != nil {
+				cw.LastApply.AppliedAt = run.StatusTimestamps.AppliedAt
+			}
+			if run.CreatedBy != nil {
+				cw.LastApply.CreatedBy = run.CreatedBy.Username
+			}
+		}
+
+		export.Workspaces = append(export.Workspaces, cw)
+	}
+
+	if format == "csv" {
+		report, err := export.CSV()
+		if err != nil {
+			return err
+		}
+		return writeReport(reportFile, report)
+	}
+
+	return c.writeJSONReport(reportFile, export)
+}
+
+// One row per Workspace, with lists joined by semicolons
+func (e *ComplianceExport) CSV() (string, error) {
+	var b strings.Builder
+
+	w := csv.NewWriter(&b)
+	if err := w.Write(COMPLIANCE_CSV_HEADER); err != nil {
+		return "", err
+	}
+
+	for _, cw := range e.Workspaces {
+		access := make([]string, len(cw.TeamAccess))
+		for idx, ta := range cw.TeamAccess {
+			access[idx] = fmt.Sprintf("%s=%s", ta.Team, ta.Access)
+		}
+
+		var lastRunID, lastAppliedAt, lastCreatedBy string
+		if cw.LastApply != nil {
+			lastRunID = cw.LastApply.RunID
+			lastAppliedAt = cw.LastApply.AppliedAt.Format(time.RFC3339)
+			lastCreatedBy = cw.LastApply.CreatedBy
+		}
+
+		record := []string{
+			cw.ID, cw.Name, cw.ExecutionMode, strconv.FormatBool(cw.AutoApply), strconv.FormatBool(cw.AllowDestroyPlan),
+			strconv.FormatBool(cw.Locked), cw.TerraformVersion, cw.VCSRepo, cw.VCSBranch, strings.Join(cw.Tags, ";"),
+			strings.Join(access, ";"), strings.Join(cw.PolicySets, ";"), lastRunID, lastAppliedAt, lastCreatedBy,
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+func containsWorkspace(workspaces []*tfe.Workspace, workspaceID string) bool {
+	for _, ws := range workspaces {
+		if ws.ID == workspaceID {
+			return true
+		}
+	}
+	return false
+}
+
+// The most recent applied Run, or nil if the Workspace has never applied
+func (c *Client) getLastApply(ctx context.Context, workspaceID string) (*tfe.Run, error) {
+	opts := &tfe.RunListOptions{
+		ListOptions: tfe.ListOptions{
+			PageSize: 1,
+		},
+		Status:  string(tfe.RunApplied),
+		Include: []tfe.RunIncludeOpt{tfe.RunCreatedBy},
+	}
+
+	runList, err := c.Runs.List(ctx, workspaceID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(runList.Items) == 0 {
+		return nil, nil
+	}
+	return runList.Items[0], nil
+}
+
+func (c *Client) getTeams(ctx context.Context, org string, include ...tfe.TeamIncludeOpt) ([]*tfe.Team, error) {
+	var teams []*tfe.Team
+
+	n := 0
+	for {
+		opts := &tfe.TeamListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			Include: include,
+		}
+
+		teamList, err := c.Teams.List(ctx, org, opts)
+		if err != nil {
+			return teams, err
+		}
+
+		teams = append(teams, teamList.Items...)
+
+		if teamList.NextPage > n {
+			n = teamList.NextPage
+		} else {
+			return teams, nil
+		}
+	}
+}
+
+func (c *Client) getTeamAccess(ctx context.Context, workspaceID string) ([]*tfe.TeamAccess, error) {
+	var access []*tfe.TeamAccess
+
+	n := 0
+	for {
+		opts := &tfe.TeamAccessListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			WorkspaceID: workspaceID,
+		}
+
+		accessList, err := c.TeamAccess.List(ctx, opts)
+		if err != nil {
+			return access, err
+		}
+
+		access = append(access, accessList.Items...)
+
+		if accessList.NextPage > n {
+			n = accessList.NextPage
+		} else {
+			return access, nil
+		}
+	}
+}
+
+func (c *Client) getPolicySets(ctx context.Context, org string) ([]*tfe.PolicySet, error) {
+	var policySets []*tfe.PolicySet
+
+	n := 0
+	for {
+		opts := &tfe.PolicySetListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			Include: []tfe.PolicySetIncludeOpt{
+				tfe.PolicySetWorkspaces,
+			},
+		}
+
+		psList, err := c.PolicySets.List(ctx, org, opts)
+		if err != nil {
+			return policySets, err
+		}
+
+		policySets = append(policySets, psList.Items...)
+
+		if psList.NextPage > n {
+			n = psList.NextPage
+		} else {
+			return policySets, nil
+		}
+	}
+}
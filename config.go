@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// Settings read from -config, or the default config file if it exists
+type Config struct {
+	// Tried in order for the host and Organization, before TFE_TOKEN
+	Tokens []TokenConfig `json:"tokens,omitempty"`
+	// Refuse every change, as with -read-only
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Flags to use for an action unless they're given, by action then flag name, e.g.
+	// {"cleanup": {"stuck-status": "planned"}}
+	Defaults map[string]map[string]any `json:"defaults,omitempty"`
+	// The Run statuses each operation may be done from, whichever action does it, e.g.
+	// {"confirm": ["planned", "cost_estimated"]}; any the API allows for operations not given
+	Actionable map[string][]tfe.RunStatus `json:"actionable,omitempty"`
+
+	// The file it was read from, empty if there was none
+	path string
+}
+
+// The operations whose statuses can be restricted with actionable
+var ACTIONABLE_OPERATIONS = []string{"confirm", "discard", "cancel"}
+
+// Every Run status, to catch typos in actionable
+var RUN_STATUSES = []tfe.RunStatus{
+	tfe.RunApplied,
+	tfe.RunApplying,
+	tfe.RunApplyQueued,
+	tfe.RunCanceled,
+	tfe.RunConfirmed,
+	tfe.RunCostEstimated,
+	tfe.RunCostEstimating,
+	tfe.RunDiscarded,
+	tfe.RunErrored,
+	tfe.RunFetching,
+	tfe.RunFetchingCompleted,
+	tfe.RunPending,
+	tfe.RunPlanned,
+	tfe.RunPlannedAndFinished,
+	tfe.RunPlanning,
+	tfe.RunPlanQueued,
+	tfe.RunPolicyChecked,
+	tfe.RunPolicyChecking,
+	tfe.RunPolicyOverride,
+	tfe.RunPolicySoftFailed,
+	tfe.RunPostPlanCompleted,
+	tfe.RunPostPlanRunning,
+	tfe.RunPrePlanCompleted,
+	tfe.RunPrePlanRunning,
+	tfe.RunQueuing,
+}
+
+// A token, and the hosts and Organizations it's for
+type TokenConfig struct {
+	Name string `json:"name,omitempty"`
+	// e.g. app.terraform.io, any host if empty
+	Host string `json:"host,omitempty"`
+	// Any Organization if empty
+	Organizations []string `json:"organizations,omitempty"`
+	Token         string   `json:"token,omitempty"`
+	// Environment variable to read the token from, to keep it out of the file
+	TokenEnv string `json:"tokenEnv,omitempty"`
+}
+
+// ~/.config/go-tfe-bulk/config.json, or wherever the platform keeps user config
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-tfe-bulk", "config.json")
+}
+
+// Read the config file; the default one is optional, but one given with -config must exist
+func loadConfig(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	cfg := &Config{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.path = path
+	for operation, statuses := range cfg.Actionable {
+		if !slices.Contains(ACTIONABLE_OPERATIONS, operation) {
+			return nil, fmt.Errorf("%s: actionable: expected operations %s, got %q", path, strings.Join(ACTIONABLE_OPERATIONS, ", "), operation)
+		}
+		if len(statuses) == 0 {
+			return nil, fmt.Errorf("%s: actionable: no statuses for %s, leave it out to allow any", path, operation)
+		}
+		for _, status := range statuses {
+			if !slices.Contains(RUN_STATUSES, status) {
+				return nil, fmt.Errorf("%s: actionable: %s: unknown run status %q", path, operation, status)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// Set the flags the config has defaults for, for any of the actions, which weren't given on the command line. If
+// two actions have different defaults for a flag, the first action's is used
+func (cfg *Config) applyDefaults(steps []string) error {
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	for _, step := range steps {
+		for name, value := range cfg.Defaults[step] {
+			if name == "action" || name == "config" {
+				return fmt.Errorf("%s: -%s can't have a default", step, name)
+			}
+			if flag.Lookup(name) == nil {
+				return fmt.Errorf("%s: unknown flag -%s", step, name)
+			}
+			if given[name] {
+				continue
+			}
+
+			if err := flag.Set(name, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("%s: -%s: %w", step, name, err)
+			}
+			given[name] = true
+			slog.Info("config default", "action", step, "flag", name)
+		}
+	}
+	return nil
+}
+
+// A token to try, and where it came from to report without revealing it
+type tokenCandidate struct {
+	token  string
+	source string
+}
+
+// Every token for the address and Organization in the order to try them: those naming the Organization, those
+// for any Organization, then TFE_TOKEN
+func (cfg *Config) tokensFor(address, org string) []string {
+	var tokens []string
+	for _, candidate := range cfg.tokenCandidates(address, org) {
+		tokens = append(tokens, candidate.token)
+	}
+	return tokens
+}
+
+func (cfg *Config) tokenCandidates(address, org string) []tokenCandidate {
+	host := address
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	var specific, general []tokenCandidate
+	for idx, tc := range cfg.Tokens {
+		if tc.Host != "" && tc.Host != host {
+			continue
+		}
+		candidate := tokenCandidate{token: tc.Token, source: tc.Name}
+		if candidate.source == "" {
+			candidate.source = fmt.Sprintf("tokens[%d]", idx)
+		}
+		if tc.TokenEnv != "" {
+			candidate.token = os.Getenv(tc.TokenEnv)
+			candidate.source += " from " + tc.TokenEnv
+		}
+		if candidate.token == "" {
+			continue
+		}
+
+		switch {
+		case slices.Contains(tc.Organizations, org):
+			specific = append(specific, candidate)
+		case len(tc.Organizations) == 0:
+			general = append(general, candidate)
+		}
+	}
+
+	candidates := append(specific, general...)
+	if token := os.Getenv("TFE_TOKEN"); token != "" && slices.IndexFunc(candidates, func(candidate tokenCandidate) bool { return candidate.token == token }) < 0 {
+		candidates = append(candidates, tokenCandidate{token: token, source: "TFE_TOKEN"})
+	}
+	return candidates
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// The outputs every Workspace's state must expose, so consumers of remote state can rely on them
+type OutputContract struct {
+	Required []string `json:"required"`
+	// Outputs which may also be exposed without being reported as extra
+	Optional []string `json:"optional,omitempty"`
+}
+
+// Where a Workspace's outputs fall short of the contract
+type OutputViolation struct {
+	Workspace string   `json:"workspace"`
+	Missing   []string `json:"missing,omitempty"`
+	Extra     []string `json:"extra,omitempty"`
+}
+
+type OutputContractReport struct {
+	Organization string             `json:"organization"`
+	Search       string             `json:"search,omitempty"`
+	Workspaces   int                `json:"workspaces"`
+	Violations   []*OutputViolation `json:"violations"`
+}
+
+func openOutputContract(path string) (*OutputContract, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	contract := &OutputContract{}
+	if err := json.Unmarshal(b, contract); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(contract.Required) == 0 {
+		return nil, fmt.Errorf("%s: expected required outputs", path)
+	}
+	return contract, nil
+}
+
+// Report the Workspace(s) whose current state is missing a required output, or exposes outputs the contract
+// doesn't list
+func (c *Client) OutputAudit(ctx context.Context, org, search, contractFile, reportFile string) error {
+	if contractFile == "" {
+		return fmt.Errorf("-output-contract is required for output-audit")
+	}
+	contract, err := openOutputContract(contractFile)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	report := &OutputContractReport{Organization: org, Search: search, Workspaces: len(workspaces), Violations: []*OutputViolation{}}
+	for _, ws := range workspaces {
+		list, err := c.StateVersionOutputs.ReadCurrent(ctx, ws.ID)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		v := contract.check(ws, list.Items)
+		if v == nil {
+			continue
+		}
+		slog.Warn("outputs violate contract", "workspace", ws.Name, "missing", v.Missing, "extra", v.Extra)
+		report.Violations = append(report.Violations, v)
+	}
+
+	slog.Info(fmt.Sprintf("Found %d of %d Workspace(s) violating the output contract", len(report.Violations), len(workspaces)))
+	return c.writeJSONReport(reportFile, report)
+}
+
+// Where the Workspace's outputs fall short, nil if they don't
+func (contract *OutputContract) check(ws *tfe.Workspace, outputs []*tfe.StateVersionOutput) *OutputViolation {
+	var names []string
+	for _, o := range outputs {
+		names = append(names, o.Name)
+	}
+
+	v := &OutputViolation{Workspace: ws.Name}
+	for _, name := range contract.Required {
+		if !slices.Contains(names, name) {
+			v.Missing = append(v.Missing, name)
+		}
+	}
+	for _, name := range names {
+		if !slices.Contains(contract.Required, name) && !slices.Contains(contract.Optional, name) {
+			v.Extra = append(v.Extra, name)
+		}
+	}
+	sort.Strings(v.Extra)
+
+	if len(v.Missing) == 0 && len(v.Extra) == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+var COST_CSV_HEADER = []string{
+	"workspace_id", "workspace", "run_id", "estimated_at", "resources", "matched_resources", "unmatched_resources",
+	"prior_monthly_cost", "proposed_monthly_cost", "delta_monthly_cost",
+}
+
+// Export the latest finished cost estimate of the Workspace(s) as CSV, Workspaces without one have empty costs
+func (c *Client) ExportCosts(ctx context.Context, org, search, reportFile string) error {
+	if !c.supports(FeatureCostEstimation) {
+		return fmt.Errorf("cost estimation is not enabled for the organization")
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.Write(COST_CSV_HEADER); err != nil {
+		return err
+	}
+
+	estimated := 0
+	for _, ws := range workspaces {
+		run, err := c.getLatestCostEstimate(ctx, ws.ID)
+		if err != nil {
+			return err
+		}
+
+		record := []string{ws.ID, ws.Name, "", "", "", "", "", "", "", ""}
+		if run == nil {
+			slog.Info("no cost estimate", "workspace", ws.Name)
+		} else {
+			ce := run.CostEstimate
+			var finishedAt time.Time
+			if ce.StatusTimestamps != nil {
+				finishedAt = ce.StatusTimestamps.FinishedAt
+			}
+			record = []string{
+				ws.ID, ws.Name, run.ID, finishedAt.Format(time.RFC3339), strconv.Itoa(ce.ResourcesCount),
+				strconv.Itoa(ce.MatchedResourcesCount), strconv.Itoa(ce.UnmatchedResourcesCount),
+				ce.PriorMonthlyCost, ce.ProposedMonthlyCost, ce.DeltaMonthlyCost,
+			}
+			estimated++
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	slog.Info(fmt.Sprintf("Found %d cost estimate(s) for %d Workspace(s)", estimated, len(workspaces)))
+	return writeReport(reportFile, b.String())
+}
+
+// The most recent Run from the first page with a finished cost estimate, or nil if there is none
+func (c *Client) getLatestCostEstimate(ctx context.Context, workspaceID string) (*tfe.Run, error) {
+	opts := &tfe.RunListOptions{
+		Include: []tfe.RunIncludeOpt{tfe.RunCostEstimate},
+	}
+
+	runList, err := c.Runs.List(ctx, workspaceID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, run := range runList.Items {
+		ce := run.CostEstimate
+		if ce != nil && ce.Status == tfe.CostEstimateFinished && ce.StatusTimestamps != nil {
+			return run, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+const dashboardRefreshInterval = 5 * time.Second
+
+const DASHBOARD_HELP = `commands:
+  /TEXT         only show Workspaces whose name contains TEXT, / alone clears it
+  c ROWS        confirm the current Run of the rows, e.g. c 1,3-5 or c all
+  d ROWS        discard
+  x ROWS        cancel
+  w             watch, refreshing every 5s until the next command
+  r or enter    refresh
+  q             quit`
+
+// Live table of the Workspace(s) and their current Runs to confirm, discard or cancel by hand
+func (c *Client) Dashboard(ctx context.Context, org, search string, minRunAge time.Duration, allowStale bool) error {
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	filter := ""
+	watching := false
+	status := ""
+	for {
+		// Always list afresh, the Runs change underneath the dashboard
+		c.selection = nil
+		workspaces, err := c.getWorkspaces(ctx, org, search)
+		if err != nil {
+			return err
+		}
+
+		var rows []*tfe.Workspace
+		for _, ws := range workspaces {
+			if strings.Contains(ws.Name, filter) {
+				rows = append(rows, ws)
+			}
+		}
+		renderDashboard(rows, filter, watching, status)
+		status = ""
+
+		var line string
+		if watching {
+			select {
+			case l, ok := <-lines:
+				if !ok {
+					return nil
+				}
+				line = l
+				watching = false
+			case <-time.After(dashboardRefreshInterval):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		} else {
+			l, ok := <-lines
+			if !ok {
+				return nil
+			}
+			line = l
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line == "r":
+		case line == "q":
+			return nil
+		case line == "w":
+			watching = true
+		case strings.HasPrefix(line, "/"):
+			filter = strings.TrimPrefix(line, "/")
+		case strings.HasPrefix(line, "c ") || strings.HasPrefix(line, "d ") || strings.HasPrefix(line, "x "):
+			selected, err := selectRows(rows, line[2:])
+			if err != nil {
+				status = err.Error()
+				continue
+			}
+			status = c.dashboardAct(ctx, line[:1], selected, minRunAge, allowStale)
+		default:
+			status = DASHBOARD_HELP
+		}
+	}
+}
+
+// Do the command for every selected row it's allowed on, returning what happened. Runs are only confirmed if they
+// pass the same checks as -action confirm
+func (c *Client) dashboardAct(ctx context.Context, command string, selected []*tfe.Workspace, minRunAge time.Duration, allowStale bool) string {
+	done := 0
+	for _, ws := range selected {
+		t := target{ws: ws, run: ws.CurrentRun}
+
+		var err error
+		switch command {
+		case "c":
+			ok, checkErr := c.confirmable(ctx, ws, t.run, minRunAge, allowStale)
+			if checkErr != nil {
+				err = checkErr
+				break
+			}
+			if !ok {
+				continue
+			}
+			err = c.confirmRun(ctx, t)
+		case "d":
+			if !c.canDiscard(ws.Name, t.run) {
+				continue
+			}
+			err = c.discardRun(ctx, t)
+		case "x":
+			if !c.canCancel(ws.Name, t.run) {
+				continue
+			}
+			err = c.cancelRun(ctx, t)
+		}
+		if err != nil {
+			return fmt.Sprintf("%s: %s", ws.Name, err)
+		}
+		done++
+	}
+	return fmt.Sprintf("%d of %d Run(s) done", done, len(selected))
+}
+
+func renderDashboard(rows []*tfe.Workspace, filter string, watching bool, status string) {
+	// Clear the screen and move to the top left
+	fmt.Print("\033[H\033[2J")
+	fmt.Printf("%s  filter: %q  watching: %t  (? for help)\n\n", time.Now().Format(time.TimeOnly), filter, watching)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tWORKSPACE\tRUN\tSTATUS\tAGE")
+	for idx, ws := range rows {
+		run := ws.CurrentRun
+		age := ""
+		if !run.CreatedAt.IsZero() {
+			age = time.Since(run.CreatedAt).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", idx+1, ws.Name, run.ID, run.Status, age)
+	}
+	w.Flush()
+
+	if status != "" {
+		fmt.Printf("\n%s\n", status)
+	}
+	fmt.Print("\n> ")
+}
+
+// Rows by 1-based number, e.g. "1,3-5", or "all"
+func selectRows(rows []*tfe.Workspace, spec string) ([]*tfe.Workspace, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "all" {
+		return rows, nil
+	}
+
+	var selected []*tfe.Workspace
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		if !isRange {
+			to = from
+		}
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid row %q", part)
+		}
+		last, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid row %q", part)
+		}
+		if first < 1 || last > len(rows) || first > last {
+			return nil, fmt.Errorf("row %q out of range 1-%d", part, len(rows))
+		}
+		selected = append(selected, rows[first-1:last]...)
+	}
+	return selected, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Bodies longer than this are cut short in the log
+const debugBodyLimit = 2048
+
+// Logs every request and response through it, with credentials and sensitive values redacted
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func (d *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("http request", "method", req.Method, "path", req.URL.RequestURI(), "body", truncate(redactBody(reqBody), debugBodyLimit))
+
+	start := time.Now()
+	resp, err := d.next.RoundTrip(req)
+	if err != nil {
+		slog.Info("http error", "method", req.Method, "path", req.URL.RequestURI(), "latency", time.Since(start), "error", err)
+		return nil, err
+	}
+	latency := time.Since(start)
+
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("http response", "method", req.Method, "path", req.URL.RequestURI(), "status", resp.StatusCode, "latency", latency,
+		"rateLimit", resp.Header.Get("X-RateLimit-Limit"), "rateLimitRemaining", resp.Header.Get("X-RateLimit-Remaining"),
+		"rateLimitReset", resp.Header.Get("X-RateLimit-Reset"), "body", truncate(redactBody(respBody), debugBodyLimit))
+
+	return resp, nil
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "..."
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugTransportRedacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"attributes":{"token":"abc.atlasv1.xyz","description":"`+strings.Repeat("x", debugBodyLimit)+`"}}}`)
+	}))
+	defer srv.Close()
+
+	var logged bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+
+	body := `{"data":{"attributes":{"key":"db_password","value":"hunter2","sensitive":true}}}`
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v2/vars", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&debugTransport{next: http.DefaultTransport}).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(resp.Body); !strings.Contains(string(b), "abc.atlasv1.xyz") {
+		t.Error("debugTransport changed the response the caller reads")
+	}
+
+	for _, secret := range []string{"abc.atlasv1.xyz", "hunter2"} {
+		if strings.Contains(logged.String(), secret) {
+			t.Errorf("logged %q: %s", secret, logged.String())
+		}
+	}
+	if !strings.Contains(logged.String(), `..."`) {
+		t.Errorf("long body wasn't truncated: %s", logged.String())
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		limit int
+		want  string
+	}{
+		{"", 4, ""},
+		{"abcd", 4, "abcd"},
+		{"abcde", 4, "abcd..."},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.limit); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// The Organization's default execution settings, which go-tfe v1.10.0 doesn't model
+type OrganizationDefaults struct {
+	ExecutionMode string
+	// Only set when the default execution mode is agent
+	AgentPoolID string
+}
+
+// Report the Workspace(s) whose execution mode or agent pool differs from the Organization's defaults, then reset
+// them to follow the defaults again
+func (c *Client) AlignDefaults(ctx context.Context, org, search string, assume bool) error {
+	defaults, err := c.getOrganizationDefaults(ctx, org)
+	if err != nil {
+		return err
+	}
+	slog.Info("organization defaults", "executionMode", defaults.ExecutionMode, "agentPoolID", defaults.AgentPoolID)
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var alignList []*tfe.Workspace
+	for _, ws := range workspaces {
+		deviates := ws.ExecutionMode != defaults.ExecutionMode ||
+			(defaults.ExecutionMode == "agent" && ws.AgentPoolID != defaults.AgentPoolID)
+		if !deviates {
+			continue
+		}
+		if !ws.Permissions.CanUpdate {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		slog.Info("can align", "workspace", ws.Name, "executionMode", ws.ExecutionMode, "agentPoolID", ws.AgentPoolID)
+		alignList = append(alignList, ws)
+	}
+
+	slog.Info(fmt.Sprintf("Found %d of %d Workspace(s) deviating from the organization defaults", len(alignList), len(workspaces)))
+
+	if confirm(len(alignList), assume) {
+		for _, ws := range alignList {
+			if err := c.pauser.wait(ctx); err != nil {
+				return err
+			}
+
+			slog.Info("aligning", "workspace", ws.Name)
+			if err := c.alignWorkspace(ctx, ws, defaults); err != nil {
+				if err := c.tolerate(ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			c.acted(ctx, "align-defaults", ws, nil)
+		}
+	}
+
+	return nil
+}
+
+// Stop the Workspace overriding the defaults where the platform has setting overwrites, so it follows later changes
+// to them too; elsewhere set the defaults on it
+func (c *Client) alignWorkspace(ctx context.Context, ws *tfe.Workspace, defaults *OrganizationDefaults) error {
+	current, err := c.getRawAttributes(ctx, fmt.Sprintf("workspaces/%s", url.PathEscape(ws.ID)))
+	if err != nil {
+		return err
+	}
+
+	if _, ok := current["setting-overwrites"]; ok {
+		return c.updateRawAttributes(ctx, ws, map[string]any{
+			"setting-overwrites": map[string]bool{"execution-mode": false, "agent-pool": false},
+		})
+	}
+
+	attrs := map[string]any{"execution-mode": defaults.ExecutionMode}
+	if defaults.ExecutionMode == "agent" {
+		attrs["agent-pool-id"] = defaults.AgentPoolID
+	}
+	return c.updateRawAttributes(ctx, ws, attrs)
+}
+
+func (c *Client) getOrganizationDefaults(ctx context.Context, org string) (*OrganizationDefaults, error) {
+	r, err := c.getRawResource(ctx, fmt.Sprintf("organizations/%s", url.PathEscape(org)))
+	if err != nil {
+		return nil, err
+	}
+
+	mode, _ := r.Attributes["default-execution-mode"].(string)
+	if mode == "" {
+		return nil, fmt.Errorf("organization default execution settings are not supported by %s", c.platform.Name)
+	}
+
+	defaults := &OrganizationDefaults{ExecutionMode: mode}
+	if mode == "agent" {
+		defaults.AgentPoolID = r.related("default-agent-pool")
+	}
+	return defaults, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Summary of the fleet over a period, suitable for scheduled reporting
+type Digest struct {
+	Organization string    `json:"organization"`
+	Search       string    `json:"search,omitempty"`
+	BatchID      string    `json:"batchID"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Workspaces   int       `json:"workspaces"`
+	RunsExecuted int       `json:"runsExecuted"`
+	RunsApplied  int       `json:"runsApplied"`
+	RunsErrored  int       `json:"runsErrored"`
+	RunsCleaned  int       `json:"runsCleaned"`
+	Failing      []string  `json:"failing"`
+	Drifted      []string  `json:"drifted"`
+}
+
+// The latest health assessment of a Workspace, not modelled by go-tfe
+type assessmentResult struct {
+	ID        string `jsonapi:"primary,assessment-results"`
+	Drifted   bool   `jsonapi:"attr,drifted"`
+	Succeeded bool   `jsonapi:"attr,succeeded"`
+}
+
+// Summarize Run activity and drift for the Workspace(s) since the given time
+func (c *Client) Digest(ctx context.Context, org, search string, since time.Duration, reportFile, webhookURL string) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	digest := &Digest{
+		Organization: org,
+		Search:       search,
+		BatchID:      c.batchID,
+		From:         time.Now().Add(-since),
+		To:           time.Now(),
+		Workspaces:   len(workspaces),
+		Failing:      []string{},
+		Drifted:      []string{},
+	}
+
+	assessments := !c.skipUnsupported(FeatureAssessments, "drift detection")
+
+	for _, ws := range workspaces {
+		runs, err := c.getRunsSince(ctx, ws.ID, digest.From)
+		if err != nil {
+			return err
+		}
+
+		failed := false
+		for _, run := range runs {
+			digest.RunsExecuted++
+			switch run.Status {
+			case tfe.RunApplied:
+				digest.RunsApplied++
+			case tfe.RunErrored:
+				digest.RunsErrored++
+				failed = true
+			case tfe.RunCanceled, tfe.RunDiscarded:
+				digest.RunsCleaned++
+			}
+		}
+		if failed {
+			digest.Failing = append(digest.Failing, ws.Name)
+		}
+
+		if assessments && ws.AssessmentsEnabled {
+			result, err := c.getCurrentAssessment(ctx, ws.ID)
+			if err != nil {
+				return err
+			}
+			if result != nil && result.Drifted {
+				digest.Drifted = append(digest.Drifted, ws.Name)
+			}
+		}
+	}
+
+	sort.Strings(digest.Failing)
+	sort.Strings(digest.Drifted)
+
+	if err := writeReport(reportFile, digest.String()); err != nil {
+		return err
+	}
+
+	if webhookURL != "" {
+		return (&webhookNotifier{url: webhookURL}).post(ctx, digest)
+	}
+
+	return nil
+}
+
+func (d *Digest) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Fleet digest for %s", d.Organization)
+	if d.Search != "" {
+		fmt.Fprintf(&b, " (search: %s)", d.Search)
+	}
+	fmt.Fprintf(&b, "\n%s to %s\n\n", d.From.Format(time.RFC3339), d.To.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Workspaces:     %d\n", d.Workspaces)
+	fmt.Fprintf(&b, "Runs executed:  %d\n", d.RunsExecuted)
+	fmt.Fprintf(&b, "Runs applied:   %d\n", d.RunsApplied)
+	fmt.Fprintf(&b, "Runs errored:   %d\n", d.RunsErrored)
+	fmt.Fprintf(&b, "Runs cleaned:   %d\n", d.RunsCleaned)
+	fmt.Fprintf(&b, "Drift detected: %d\n", len(d.Drifted))
+
+	if len(d.Failing) > 0 {
+		fmt.Fprintf(&b, "\nFailing workspaces:\n  %s\n", strings.Join(d.Failing, "\n  "))
+	}
+	if len(d.Drifted) > 0 {
+		fmt.Fprintf(&b, "\nDrifted workspaces:\n  %s\n", strings.Join(d.Drifted, "\n  "))
+	}
+
+	return b.String()
+}
+
+// Runs are listed newest first, so stop paging once a Run predates the cutoff
+func (c *Client) getRunsSince(ctx context.Context, workspaceID string, since time.Time) ([]*tfe.Run, error) {
+	var runs []*tfe.Run
+
+	n := 0
+	for {
+		opts := &tfe.RunListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+		}
+
+		runList, err := c.Runs.List(ctx, workspaceID, opts)
+		if err != nil {
+			return runs, err
+		}
+
+		for _, run := range runList.Items {
+			if run.CreatedAt.Before(since) {
+				return runs, nil
+			}
+			runs = append(runs, run)
+		}
+
+		if runList.NextPage > n {
+			n = runList.NextPage
+		} else {
+			return runs, nil
+		}
+	}
+}
+
+func (c *Client) getCurrentAssessment(ctx context.Context, workspaceID string) (*assessmentResult, error) {
+	req, err := c.NewRequest("GET", fmt.Sprintf("workspaces/%s/current-assessment-result", workspaceID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &assessmentResult{}
+	if err := req.Do(ctx, result); err != nil {
+		// No assessment has completed yet
+		if errors.Is(err, tfe.ErrResourceNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Past Runs of each Workspace read for its plan and apply durations
+const estimateHistory = 10
+
+// Shown in the confirmation prompt, then cleared
+var batchEstimate string
+
+// Estimate how long the batch will take from each Workspace's recent plan and apply durations, for the confirmation
+// prompt: run parallel at a time, or as the throttle allows, or all at once. The action decides which phases each
+// Workspace waits for: run plans (and applies where auto-apply is on), confirm applies, and apply does both
+func (c *Client) estimateBatch(ctx context.Context, workspaces []*tfe.Workspace, action string, parallel int) error {
+	if !c.estimate || len(workspaces) == 0 {
+		return nil
+	}
+
+	var (
+		durations []time.Duration
+		missing   int
+	)
+	for _, ws := range workspaces {
+		plan, apply, err := c.runDurations(ctx, ws.ID)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+		}
+
+		needPlan := action != "confirm"
+		needApply := action == "confirm" || action == "apply" || ws.AutoApply || c.autoApply
+		if (needPlan && plan == 0) || (needApply && apply == 0) {
+			missing++
+			continue
+		}
+
+		var d time.Duration
+		if needPlan {
+			d += plan
+		}
+		if needApply {
+			d += apply
+		}
+		slog.Debug("estimated", "workspace", ws.Name, "duration", d)
+		durations = append(durations, d)
+	}
+
+	if len(durations) == 0 {
+		slog.Info("No Run history to estimate the batch from")
+		return nil
+	}
+
+	// Workspaces without history are assumed to take the typical time
+	typical := median(durations)
+	for i := 0; i < missing; i++ {
+		durations = append(durations, typical)
+	}
+
+	if parallel == 0 && c.throttler != nil {
+		parallel = max(c.throttler.limit-c.throttler.headroom, 1)
+	}
+	total := makespan(durations, parallel)
+	at := "all at once"
+	if parallel > 0 {
+		at = fmt.Sprintf("%d at a time", parallel)
+	}
+	slog.Info(fmt.Sprintf("Estimated %s for %d Workspace(s) %s, %d without history", total, len(workspaces), at, missing))
+	batchEstimate = fmt.Sprintf("~%s", total)
+	return nil
+}
+
+// The median plan and apply durations of the Workspace's recent Runs, 0 where there are none
+func (c *Client) runDurations(ctx context.Context, workspaceID string) (time.Duration, time.Duration, error) {
+	runList, err := c.Runs.List(ctx, workspaceID, &tfe.RunListOptions{
+		ListOptions: tfe.ListOptions{PageSize: estimateHistory},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var plans, applies []time.Duration
+	for _, run := range runList.Items {
+		ts := run.StatusTimestamps
+		if ts == nil || ts.PlanningAt.IsZero() {
+			continue
+		}
+		planned := ts.PlannedAt
+		if planned.IsZero() {
+			planned = ts.PlannedAndFinishedAt
+		}
+		if !planned.IsZero() {
+			plans = append(plans, planned.Sub(ts.PlanningAt))
+		}
+		if !ts.ApplyingAt.IsZero() && !ts.AppliedAt.IsZero() {
+			applies = append(applies, ts.AppliedAt.Sub(ts.ApplyingAt))
+		}
+	}
+
+	return median(plans), median(applies), nil
+}
+
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// How long the durations take run parallel at a time, longest first onto whichever slot frees up first
+func makespan(durations []time.Duration, parallel int) time.Duration {
+	if parallel <= 0 || parallel > len(durations) {
+		parallel = len(durations)
+	}
+
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+
+	slots := make([]time.Duration, parallel)
+	for _, d := range sorted {
+		next := 0
+		for i := range slots {
+			if slots[i] < slots[next] {
+				next = i
+			}
+		}
+		slots[next] += d
+	}
+
+	var total time.Duration
+	for _, slot := range slots {
+		total = max(total, slot)
+	}
+	return total.Round(time.Second)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+)
+
+// Also write every log record, including Debug ones such as "selected" and "acted", as one JSON object per
+// line to the file, so other processes can follow the batch
+func streamEvents(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	addLogHandler(slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return nil
+}
+
+// Send every log record to h as well as to wherever it already goes
+func addLogHandler(h slog.Handler) {
+	slog.SetDefault(slog.New(&teeHandler{handlers: []slog.Handler{slog.Default().Handler(), h}}))
+
+	// SetDefault routes the log package through slog, but the default handler writes through the log package
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags)
+}
+
+// Sends records to every handler which is enabled for them
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func (t *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(t.handlers))
+	for idx, h := range t.handlers {
+		handlers[idx] = h.WithAttrs(attrs)
+	}
+	return &teeHandler{handlers: handlers}
+}
+
+func (t *teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(t.handlers))
+	for idx, h := range t.handlers {
+		handlers[idx] = h.WithGroup(name)
+	}
+	return &teeHandler{handlers: handlers}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+// Outcome counts besides the operations acted on, e.g. "confirm"
+const OutcomeSkipped = "skipped"
+
+// How many times an outcome should happen: a number for exactly that many, or {"min": 1, "max": 5}
+type Expectation struct {
+	Min *int `json:"min,omitempty"`
+	Max *int `json:"max,omitempty"`
+}
+
+func (e *Expectation) UnmarshalJSON(b []byte) error {
+	var exactly int
+	if err := json.Unmarshal(b, &exactly); err == nil {
+		e.Min, e.Max = &exactly, &exactly
+		return nil
+	}
+
+	type bounds Expectation
+	if err := json.Unmarshal(b, (*bounds)(e)); err != nil {
+		return err
+	}
+	if e.Min == nil && e.Max == nil {
+		return fmt.Errorf("expected a count, min or max")
+	}
+	return nil
+}
+
+func (e *Expectation) String() string {
+	switch {
+	case e.Min != nil && e.Max != nil && *e.Min == *e.Max:
+		return fmt.Sprint(*e.Min)
+	case e.Max == nil:
+		return fmt.Sprintf(">= %d", *e.Min)
+	case e.Min == nil:
+		return fmt.Sprintf("<= %d", *e.Max)
+	}
+	return fmt.Sprintf("%d-%d", *e.Min, *e.Max)
+}
+
+func (e *Expectation) met(count int) bool {
+	return (e.Min == nil || count >= *e.Min) && (e.Max == nil || count <= *e.Max)
+}
+
+// Expected outcomes by operation, or OutcomeSkipped for Workspaces skipped over errors, e.g.
+// {"confirm": 42, "skipped": 0}; outcomes not listed can happen any number of times
+func openExpectations(path string) (map[string]*Expectation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := map[string]*Expectation{}
+	if err := json.Unmarshal(b, &expected); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return expected, nil
+}
+
+// Compare what the batch did with the expectations, printing a diff of those it deviated from
+func (c *Client) meetsExpectations(expected map[string]*Expectation) bool {
+	actual := map[string]int{}
+	for operation, count := range c.outcomes {
+		actual[operation] = count
+	}
+	for _, count := range c.skipped {
+		actual[OutcomeSkipped] += count
+	}
+
+	outcomes := make([]string, 0, len(expected))
+	for outcome := range expected {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+
+	deviated := 0
+	for _, outcome := range outcomes {
+		if e := expected[outcome]; !e.met(actual[outcome]) {
+			fmt.Printf("- %s: %s\n+ %s: %d\n", outcome, e, outcome, actual[outcome])
+			deviated++
+		}
+	}
+
+	if deviated > 0 {
+		slog.Error(fmt.Sprintf("Outcome deviated from %d of %d expectation(s)", deviated, len(expected)))
+		return false
+	}
+	slog.Info(fmt.Sprintf("Outcome met all %d expectation(s)", len(expected)))
+	return true
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// The decisions made on each Workspace with -explain or -report-untouched, nil without
+var explanation *explainTrace
+
+type explainTrace struct {
+	// Print the trace before the confirmation prompt, with -explain
+	print bool
+
+	mu     sync.Mutex
+	traces map[string][]string
+	// Lines of each Workspace's trace already printed
+	printed map[string]int
+	// Workspaces in the order they were first mentioned
+	order []string
+}
+
+// Also collect every record about a Workspace, including Debug ones such as "listed" and "selected"
+func explainDecisions(print bool) {
+	explanation = &explainTrace{print: print, traces: map[string][]string{}, printed: map[string]int{}}
+	addLogHandler(&explainHandler{trace: explanation})
+}
+
+func (t *explainTrace) add(workspace, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.traces[workspace]; !ok {
+		t.order = append(t.order, workspace)
+	}
+	t.traces[workspace] = append(t.traces[workspace], line)
+}
+
+// Print what was traced of every Workspace since the last flush, with -explain
+func (t *explainTrace) flush(w io.Writer) {
+	if t == nil || !t.print {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	header := false
+	for _, workspace := range t.order {
+		lines := t.traces[workspace][t.printed[workspace]:]
+		if len(lines) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, "Decisions:")
+			header = true
+		}
+		fmt.Fprintf(w, "  %s\n", workspace)
+		for _, line := range lines {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+		t.printed[workspace] = len(t.traces[workspace])
+	}
+}
+
+// The last decision traced on the Workspace, or "" if there's none
+func (t *explainTrace) last(workspace string) string {
+	if t == nil {
+		return ""
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	trace := t.traces[workspace]
+	if len(trace) == 0 {
+		return ""
+	}
+	return trace[len(trace)-1]
+}
+
+// Adds records with a workspace attribute to the trace
+type explainHandler struct {
+	attrs []slog.Attr
+	trace *explainTrace
+}
+
+func (e *explainHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (e *explainHandler) Handle(ctx context.Context, r slog.Record) error {
+	workspace := ""
+	var detail []string
+	add := func(a slog.Attr) bool {
+		if a.Key == "workspace" {
+			workspace = a.Value.String()
+		} else {
+			detail = append(detail, fmt.Sprintf("%s=%s", a.Key, a.Value))
+		}
+		return true
+	}
+	for _, a := range e.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+	if workspace == "" {
+		return nil
+	}
+
+	line := r.Message
+	if len(detail) > 0 {
+		line += " " + strings.Join(detail, " ")
+	}
+	e.trace.add(workspace, line)
+	return nil
+}
+
+func (e *explainHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &explainHandler{attrs: append(append([]slog.Attr{}, e.attrs...), attrs...), trace: e.trace}
+}
+
+// The tool doesn't use groups, so records are traced as if there were none
+func (e *explainHandler) WithGroup(name string) slog.Handler {
+	return e
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Never lets two mutations of the same Workspace be in flight at once, however they're issued, since concurrent
+// changes to a Workspace or its Runs conflict. It sits outside conflictRetry, so retries hold the Workspace too
+type workspaceFence struct {
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+	// The Workspace of every Run seen in a response, as Run paths don't name it
+	runs map[string]string
+}
+
+func newWorkspaceFence(next http.RoundTripper) *workspaceFence {
+	return &workspaceFence{next: next, locks: map[string]*sync.Mutex{}, runs: map[string]string{}}
+}
+
+func (f *workspaceFence) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet {
+		resp, err := f.next.RoundTrip(req)
+		if err == nil && resp.StatusCode < 300 && strings.Contains(resp.Header.Get("Content-Type"), "json") {
+			f.learn(&resp.Body)
+		}
+		return resp, err
+	}
+
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if id := f.workspaceOf(req.URL.Path, body); id != "" {
+		lock := f.lock(id)
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	return f.next.RoundTrip(req)
+}
+
+func (f *workspaceFence) lock(id string) *sync.Mutex {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.locks[id] == nil {
+		f.locks[id] = &sync.Mutex{}
+	}
+	return f.locks[id]
+}
+
+// The Workspace a mutation changes, from its path or, when creating a Run, its body; empty if it isn't known
+func (f *workspaceFence) workspaceOf(path string, body []byte) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for idx := 0; idx+1 < len(segments); idx++ {
+		id := segments[idx+1]
+		switch {
+		case segments[idx] == "workspaces" && strings.HasPrefix(id, "ws-"):
+			return id
+		case segments[idx] == "runs" && strings.HasPrefix(id, "run-"):
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if ws := f.runs[id]; ws != "" {
+				return ws
+			}
+			// Runs of an unknown Workspace are at least fenced from themselves
+			return id
+		}
+	}
+
+	if len(segments) > 0 && segments[len(segments)-1] == "runs" {
+		var doc struct {
+			Data jsonapiResource `json:"data"`
+		}
+		if json.Unmarshal(body, &doc) == nil {
+			return doc.Data.related("workspace")
+		}
+	}
+	return ""
+}
+
+type jsonapiResource struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	Relationships map[string]struct {
+		Data *struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	} `json:"relationships"`
+}
+
+// The ID of a to-one relationship, or empty
+func (r jsonapiResource) related(name string) string {
+	if rel, ok := r.Relationships[name]; ok && rel.Data != nil {
+		return rel.Data.ID
+	}
+	return ""
+}
+
+// Remember the Workspace of the Runs in a response, and of the Workspaces' current and latest Runs
+func (f *workspaceFence) learn(body *io.ReadCloser) {
+	b, err := readBody(body)
+	if err != nil || len(b) == 0 {
+		return
+	}
+
+	var doc struct {
+		Data     json.RawMessage   `json:"data"`
+		Included []jsonapiResource `json:"included"`
+	}
+	if json.Unmarshal(b, &doc) != nil {
+		return
+	}
+	var resources []jsonapiResource
+	if json.Unmarshal(doc.Data, &resources) != nil {
+		var one jsonapiResource
+		if json.Unmarshal(doc.Data, &one) != nil {
+			return
+		}
+		resources = []jsonapiResource{one}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range append(resources, doc.Included...) {
+		switch r.Type {
+		case "runs":
+			if ws := r.related("workspace"); ws != "" {
+				f.runs[r.ID] = ws
+			}
+		case "workspaces":
+			for _, name := range []string{"current-run", "latest-run"} {
+				if run := r.related(name); run != "" {
+					f.runs[run] = r.ID
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Headers a fixture keeps, those the tool and go-tfe read; the rest only add noise to a bug report
+var FIXTURE_HEADERS = []string{"Content-Type", "TFP-API-Version", "TFP-AppName", "X-TFE-Version", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"}
+
+// Cut a -record cassette down to the reads an action decided from, as a fixture for -replay: mutations are dropped,
+// so replaying it without -assume-yes reproduces the decisions of a reported scenario, and the address and
+// unneeded headers are dropped so it reads well in a bug report
+func WriteFixture(cassette, fixture string) error {
+	in, err := os.Open(cassette)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(fixture)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	kept, total := 0, 0
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		interaction := Interaction{}
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return fmt.Errorf("%s:%d: %w", cassette, line, err)
+		}
+		total++
+		if interaction.Request.Method != http.MethodGet {
+			continue
+		}
+
+		u, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", cassette, line, err)
+		}
+		interaction.Request.URL = u.RequestURI()
+		interaction.Request.Headers = nil
+		interaction.Response.Headers = fixtureHeaders(interaction.Response.Headers)
+		interaction.Duration = 0
+
+		b, err := json.Marshal(interaction)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(append(b, '\n')); err != nil {
+			return err
+		}
+		kept++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	slog.Info(fmt.Sprintf("Kept %d of %d interaction(s)", kept, total), "fixture", fixture)
+	return nil
+}
+
+func fixtureHeaders(h http.Header) http.Header {
+	kept := http.Header{}
+	for _, key := range FIXTURE_HEADERS {
+		if value := h.Get(key); value != "" {
+			kept.Set(key, value)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// The environment Variable a -lock workspace is locked with; it's never used by a Run there
+const LOCK_VARIABLE = "GO_TFE_BULK_LOCK"
+
+// Who holds the fleet lock, so two operators can't run conflicting batches against the same Organization at once
+type FleetLock struct {
+	BatchID      string    `json:"batchID"`
+	Organization string    `json:"organization"`
+	Actions      []string  `json:"actions"`
+	Host         string    `json:"host"`
+	PID          int       `json:"pid"`
+	AcquiredAt   time.Time `json:"acquiredAt"`
+	// A lock left behind by a batch which was killed can be taken over after this
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (l *FleetLock) String() string {
+	return fmt.Sprintf("batch %s of %s on %s (pid %d) since %s, until %s", l.BatchID, strings.Join(l.Actions, ","), l.Host, l.PID, l.AcquiredAt.Format(time.RFC3339), l.ExpiresAt.Format(time.RFC3339))
+}
+
+// A lock which has been taken, to release once the batch is over
+type heldLock struct {
+	c    *Client
+	lock *FleetLock
+	// One of these, as -lock gave
+	path        string
+	workspaceID string
+	variableID  string
+
+	// Stops the heartbeat renewing ExpiresAt, once it's closed
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Take the lock -lock gives as file:PATH, a lockfile shared by operators on one host or a network filesystem, or
+// workspace:NAME, a Variable on a Workspace of the Organization set aside for it
+func (c *Client) lockFleet(ctx context.Context, spec, org string, actions []string, ttl time.Duration) (*heldLock, error) {
+	if ttl < time.Minute {
+		return nil, fmt.Errorf("-lock-ttl must be at least a minute, got %s", ttl)
+	}
+
+	host, _ := os.Hostname()
+	now := time.Now().UTC()
+	lock := &FleetLock{BatchID: c.batchID, Organization: org, Actions: actions, Host: host, PID: os.Getpid(), AcquiredAt: now, ExpiresAt: now.Add(ttl)}
+
+	kind, target, _ := strings.Cut(spec, ":")
+	if target == "" {
+		return nil, fmt.Errorf("-lock: expected file:PATH or workspace:NAME, got %q", spec)
+	}
+
+	var held *heldLock
+	var err error
+	switch kind {
+	case "file":
+		held, err = lockFile(target, lock)
+	case "workspace":
+		held, err = c.lockWorkspace(ctx, org, target, lock)
+	default:
+		return nil, fmt.Errorf("-lock: expected file:PATH or workspace:NAME, got %q", spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	held.c, held.lock = c, lock
+	slog.Info("locked", "lock", spec, "batchID", c.batchID, "expires", lock.ExpiresAt.Format(time.RFC3339))
+
+	held.stop, held.done = make(chan struct{}), make(chan struct{})
+	go held.heartbeat(ttl)
+	return held, nil
+}
+
+// Renew the lock a third of the way through its TTL until it's released, so a batch running longer than the TTL,
+// e.g. an apply pipeline or -approval-watch, keeps it. Only a batch which was killed stops renewing
+func (h *heldLock) heartbeat(ttl time.Duration) {
+	defer close(h.done)
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		}
+
+		h.lock.ExpiresAt = time.Now().UTC().Add(ttl)
+		if err := h.renew(context.Background()); err != nil {
+			slog.Warn("unable to renew lock", "error", err)
+			continue
+		}
+		slog.Debug("renewed lock", "batchID", h.lock.BatchID, "expires", h.lock.ExpiresAt.Format(time.RFC3339))
+	}
+}
+
+// Write the lock's new expiry, unless another batch took it over
+func (h *heldLock) renew(ctx context.Context) error {
+	if h.path != "" {
+		existing, err := readFleetLock(h.path)
+		if err != nil {
+			return err
+		}
+		if existing.BatchID != h.lock.BatchID {
+			return fmt.Errorf("lock %s was taken over by batch %s", h.path, existing.BatchID)
+		}
+		b, err := json.MarshalIndent(h.lock, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(h.path, append(b, '\n'), 0o644)
+	}
+
+	b, err := json.Marshal(h.lock)
+	if err != nil {
+		return err
+	}
+	_, err = h.c.Variables.Update(ctx, h.workspaceID, h.variableID, tfe.VariableUpdateOptions{Value: tfe.String(string(b))})
+	return err
+}
+
+// The lockfile is created exclusively, so only one batch can, and taken over once it's expired
+func lockFile(path string, lock *FleetLock) (*heldLock, error) {
+	b, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			defer f.Close()
+			if _, err := f.Write(append(b, '\n')); err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return &heldLock{path: path}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) || attempt > 0 {
+			return nil, err
+		}
+
+		existing, err := readFleetLock(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the lock %s, remove it if no batch holds it: %w", path, err)
+		}
+		if time.Now().Before(existing.ExpiresAt) {
+			return nil, fmt.Errorf("fleet lock %s is held by %s", path, existing)
+		}
+		slog.Warn("taking over expired lock", "lock", path, "heldBy", existing.BatchID)
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readFleetLock(path string) (*FleetLock, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lock := &FleetLock{}
+	return lock, json.Unmarshal(b, lock)
+}
+
+// A Workspace can only have one Variable with a key in a category, so only one batch can create it
+func (c *Client) lockWorkspace(ctx context.Context, org, name string, lock *FleetLock) (*heldLock, error) {
+	ws, err := c.Workspaces.Read(ctx, org, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the lock workspace %s: %w", name, err)
+	}
+
+	b, err := json.Marshal(lock)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		v, createErr := c.Variables.Create(ctx, ws.ID, tfe.VariableCreateOptions{
+			Key:         tfe.String(LOCK_VARIABLE),
+			Value:       tfe.String(string(b)),
+			Category:    tfe.Category(tfe.CategoryEnv),
+			Description: tfe.String("Held by go-tfe-bulk while a batch runs against the organization"),
+		})
+		if createErr == nil {
+			return &heldLock{workspaceID: ws.ID, variableID: v.ID}, nil
+		}
+		if attempt > 0 {
+			return nil, createErr
+		}
+
+		variables, err := c.getVariables(ctx, ws.ID)
+		if err != nil {
+			return nil, err
+		}
+		var existing *tfe.Variable
+		for _, v := range variables {
+			if v.Key == LOCK_VARIABLE && v.Category == tfe.CategoryEnv {
+				existing = v
+			}
+		}
+		if existing == nil {
+			return nil, fmt.Errorf("unable to lock workspace %s: %w", name, createErr)
+		}
+
+		held := &FleetLock{}
+		if err := json.Unmarshal([]byte(existing.Value), held); err != nil {
+			return nil, fmt.Errorf("unable to read the lock on workspace %s, delete its %s variable if no batch holds it: %w", name, LOCK_VARIABLE, err)
+		}
+		if time.Now().Before(held.ExpiresAt) {
+			return nil, fmt.Errorf("fleet lock on workspace %s is held by %s", name, held)
+		}
+		slog.Warn("taking over expired lock", "lock", name, "heldBy", held.BatchID)
+		if err := c.Variables.Delete(ctx, ws.ID, existing.ID); err != nil && !errors.Is(err, tfe.ErrResourceNotFound) {
+			return nil, err
+		}
+	}
+}
+
+// Release the lock if it's still this batch's; failures are logged, the lock expires regardless
+func (h *heldLock) unlock(ctx context.Context) {
+	if h == nil {
+		return
+	}
+	close(h.stop)
+	<-h.done
+
+	var err error
+	if h.path != "" {
+		var existing *FleetLock
+		if existing, err = readFleetLock(h.path); err == nil && existing.BatchID == h.lock.BatchID {
+			err = os.Remove(h.path)
+		}
+	} else if err = h.c.Variables.Delete(ctx, h.workspaceID, h.variableID); errors.Is(err, tfe.ErrResourceNotFound) {
+		// Taken over once it expired
+		err = nil
+	}
+	if err != nil {
+		slog.Warn("unable to release lock", "error", err)
+		return
+	}
+	slog.Debug("unlocked", "batchID", h.lock.BatchID)
+}
@@ -5,6 +5,7 @@ go 1.21.0
 require (
 	github.com/hashicorp/go-tfe v1.10.0
 	golang.org/x/exp v0.0.0-20221006183845-316c7553db56
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// Workspaces without a value for -group-by go last
+const ungrouped = "(none)"
+
+// Whether -group-by is "project" or "tag:KEY"
+func validGroupBy(groupBy string) bool {
+	key, isTag := strings.CutPrefix(groupBy, "tag:")
+	return groupBy == "" || groupBy == "project" || (isTag && key != "")
+}
+
+const (
+	// A failed Run stops the whole batch
+	GateStop = "stop"
+	// A failed Run skips the Workspace(s) its Workspace run-triggers in later groups
+	GateSkipDownstream = "skip-downstream"
+)
+
+var GATES = []string{GateStop, GateSkipDownstream}
+
+// What to do on a failed Run from -gate "on-failure=stop|skip-downstream", or "" if it's invalid
+func parseGate(gate string) string {
+	value, ok := strings.CutPrefix(gate, "on-failure=")
+	if !ok || !slices.Contains(GATES, value) {
+		return ""
+	}
+	return value
+}
+
+// Statuses where a Run has finished without doing what it was started or confirmed for
+var FAILED_STATUSES = []tfe.RunStatus{
+	tfe.RunErrored,
+	tfe.RunCanceled,
+	tfe.RunPolicySoftFailed,
+}
+
+// Process the Workspace(s) one group at a time in name order, if -group-by was given, optionally waiting for
+// each group's Runs to finish before starting the next and gating later groups on them
+func (c *Client) inGroups(ctx context.Context, org string, workspaces []*tfe.Workspace, process func([]*tfe.Workspace) ([]*tfe.Run, error)) error {
+	if c.groupBy == "" {
+		_, err := c.inBatches(ctx, workspaces, process)
+		return err
+	}
+
+	names, groups, err := c.groupWorkspaces(ctx, org, c.groupBy, workspaces)
+	if err != nil {
+		return err
+	}
+
+	// Workspaces whose Run failed, and those skipped downstream of them
+	failed := map[string]bool{}
+	for idx, name := range names {
+		group := groups[name]
+		if len(failed) > 0 {
+			if group, err = c.skipDownstream(ctx, group, failed); err != nil {
+				return err
+			}
+		}
+
+		slog.Info("processing group", "group", name, "workspaces", len(group))
+		runs, err := c.inBatches(ctx, group, process)
+		if err != nil {
+			return err
+		}
+
+		if (c.waitGroups || c.gate != "") && idx < len(names)-1 && len(runs) > 0 {
+			finished, err := c.waitForRuns(ctx, runs)
+			if err != nil {
+				return err
+			}
+
+			for _, run := range finished {
+				if !slices.Contains(FAILED_STATUSES, run.Status) || run.Workspace == nil {
+					continue
+				}
+				slog.Warn("run failed", "group", name, "workspaceID", run.Workspace.ID, "runID", run.ID, "status", run.Status)
+				failed[run.Workspace.ID] = true
+			}
+
+			if len(failed) > 0 && c.gate == GateStop {
+				return fmt.Errorf("%d Run(s) in group %s failed, stopping before group %s", len(failed), name, names[idx+1])
+			}
+		}
+	}
+
+	return nil
+}
+
+// Drop the Workspace(s) run-triggered by a failed one, marking them failed in turn so the skip carries on downstream
+func (c *Client) skipDownstream(ctx context.Context, workspaces []*tfe.Workspace, failed map[string]bool) ([]*tfe.Workspace, error) {
+	var kept []*tfe.Workspace
+	for _, ws := range workspaces {
+		sources, err := c.getRunTriggerSources(ctx, ws.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		upstream := ""
+		for _, source := range sources {
+			if failed[source.ID] {
+				upstream = source.Name
+				break
+			}
+		}
+		if upstream != "" {
+			slog.Warn("skipping, upstream run failed", "workspace", ws.Name, "upstream", upstream)
+			failed[ws.ID] = true
+			continue
+		}
+
+		kept = append(kept, ws)
+	}
+	return kept, nil
+}
+
+// The Workspace(s) whose applies queue Runs in this one
+func (c *Client) getRunTriggerSources(ctx context.Context, workspaceID string) ([]*tfe.Workspace, error) {
+	var sources []*tfe.Workspace
+
+	n := 0
+	for {
+		opts := &tfe.RunTriggerListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			RunTriggerType: tfe.RunTriggerInbound,
+		}
+
+		triggerList, err := c.RunTriggers.List(ctx, workspaceID, opts)
+		if err != nil {
+			return sources, err
+		}
+
+		for _, trigger := range triggerList.Items {
+			if trigger.Sourceable != nil {
+				trigger.Sourceable.Name = trigger.SourceableName
+				sources = append(sources, trigger.Sourceable)
+			}
+		}
+
+		if triggerList.NextPage > n {
+			n = triggerList.NextPage
+		} else {
+			return sources, nil
+		}
+	}
+}
+
+// Process the Workspace(s) -batch-size at a time, pausing between batches, if -batch-size was given
+func (c *Client) inBatches(ctx context.Context, workspaces []*tfe.Workspace, process func([]*tfe.Workspace) ([]*tfe.Run, error)) ([]*tfe.Run, error) {
+	if c.batchSize <= 0 {
+		return process(workspaces)
+	}
+
+	var runs []*tfe.Run
+	for start := 0; start < len(workspaces); start += c.batchSize {
+		if start > 0 && c.batchPause > 0 {
+			slog.Info("pausing between batches", "pause", c.batchPause, "remaining", len(workspaces)-start)
+			if err := sleep(ctx, c.batchPause); err != nil {
+				return runs, err
+			}
+		}
+
+		batch := workspaces[start:min(start+c.batchSize, len(workspaces))]
+		slog.Info("processing batch", "size", len(batch), "remaining", len(workspaces)-start-len(batch))
+		batchRuns, err := process(batch)
+		runs = append(runs, batchRuns...)
+		if err != nil {
+			return runs, err
+		}
+	}
+
+	return runs, nil
+}
+
+func (c *Client) groupWorkspaces(ctx context.Context, org, groupBy string, workspaces []*tfe.Workspace) ([]string, map[string][]*tfe.Workspace, error) {
+	var groupOf func(ws *tfe.Workspace) string
+	if key, isTag := strings.CutPrefix(groupBy, "tag:"); isTag {
+		groupOf = func(ws *tfe.Workspace) string { return tagValue(ws, key) }
+	} else {
+		projects, err := c.getProjectNames(ctx, org)
+		if err != nil {
+			return nil, nil, err
+		}
+		groupOf = func(ws *tfe.Workspace) string { return projects[ws.ID] }
+	}
+
+	var names []string
+	groups := map[string][]*tfe.Workspace{}
+	for _, ws := range workspaces {
+		name := groupOf(ws)
+		if name == "" {
+			name = ungrouped
+		}
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], ws)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == ungrouped || names[j] == ungrouped {
+			return names[j] == ungrouped && names[i] != ungrouped
+		}
+		return names[i] < names[j]
+	})
+
+	return names, groups, nil
+}
+
+// Take one Workspace from each group in turn, keeping the -sort order within groups, with -interleave-by
+func (c *Client) interleaveWorkspaces(ctx context.Context, org string, workspaces []*tfe.Workspace) ([]*tfe.Workspace, error) {
+	if c.interleave == "" {
+		return workspaces, nil
+	}
+
+	names, groups, err := c.groupWorkspaces(ctx, org, c.interleave, workspaces)
+	if err != nil {
+		return nil, err
+	}
+
+	interleaved := make([]*tfe.Workspace, 0, len(workspaces))
+	for round := 0; len(interleaved) < len(workspaces); round++ {
+		for _, name := range names {
+			if round < len(groups[name]) {
+				interleaved = append(interleaved, groups[name][round])
+			}
+		}
+	}
+	slog.Debug("interleaved", "by", c.interleave, "groups", len(names))
+	return interleaved, nil
+}
+
+// The value of a "key:value" tag, or "" if the Workspace has none
+func tagValue(ws *tfe.Workspace, key string) string {
+	for _, tag := range ws.TagNames {
+		if k, v, ok := strings.Cut(tag, ":"); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+var HISTORY_CSV_HEADER = []string{
+	"workspace_id", "workspace", "run_id", "status", "source", "created_at", "created_by", "is_destroy", "plan_only",
+	"has_changes", "queued_seconds", "planning_seconds", "applying_seconds", "total_seconds", "applied_at", "applied_by",
+}
+
+// Marks Runs applied without anyone confirming them in the applier column
+const appliedByAutoApply = "auto-apply"
+
+// The Workspace(s)' recent Runs, for loading into a data warehouse
+type HistoryExport struct {
+	Organization string        `json:"organization"`
+	Search       string        `json:"search,omitempty"`
+	GeneratedAt  time.Time     `json:"generatedAt"`
+	Runs         []*HistoryRun `json:"runs"`
+}
+
+type HistoryRun struct {
+	WorkspaceID string        `json:"workspaceID"`
+	Workspace   string        `json:"workspace"`
+	RunID       string        `json:"runID"`
+	Status      tfe.RunStatus `json:"status"`
+	Source      string        `json:"source"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	CreatedBy   string        `json:"createdBy,omitempty"`
+	IsDestroy   bool          `json:"isDestroy"`
+	PlanOnly    bool          `json:"planOnly"`
+	HasChanges  bool          `json:"hasChanges"`
+	// The time spent in each stage, nil for those the Run didn't reach
+	QueuedSeconds   *int       `json:"queuedSeconds"`
+	PlanningSeconds *int       `json:"planningSeconds"`
+	ApplyingSeconds *int       `json:"applyingSeconds"`
+	TotalSeconds    *int       `json:"totalSeconds"`
+	AppliedAt       *time.Time `json:"appliedAt"`
+	// Who confirmed the apply, or auto-apply
+	AppliedBy string `json:"appliedBy,omitempty"`
+}
+
+// Export up to the last Runs of each of the Workspace(s), newest first, as JSON or CSV
+func (c *Client) History(ctx context.Context, org, search string, last int, format, reportFile string) error {
+	if last <= 0 {
+		return fmt.Errorf("-last must be positive for history")
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	export := &HistoryExport{Organization: org, Search: search, GeneratedAt: time.Now().UTC(), Runs: []*HistoryRun{}}
+	usernames := map[string]string{}
+	for _, ws := range workspaces {
+		runs, err := c.getLastRuns(ctx, ws.ID, last)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, run := range runs {
+			hr := newHistoryRun(ws, run)
+			if hr.AppliedAt != nil && !run.AutoApply {
+				if hr.AppliedBy, err = c.getConfirmedBy(ctx, run, usernames); err != nil {
+					if err := c.tolerate(ws.Name, err); err != nil {
+						return err
+					}
+				}
+			}
+			export.Runs = append(export.Runs, hr)
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Exported %d Run(s) for %d Workspace(s)", len(export.Runs), len(workspaces)))
+
+	if format == "csv" {
+		report, err := export.CSV()
+		if err != nil {
+			return err
+		}
+		return writeReport(reportFile, report)
+	}
+	return c.writeJSONReport(reportFile, export)
+}
+
+func newHistoryRun(ws *tfe.Workspace, run *tfe.Run) *HistoryRun {
+	hr := &HistoryRun{
+		WorkspaceID: ws.ID,
+		Workspace:   ws.Name,
+		RunID:       run.ID,
+		Status:      run.Status,
+		Source:      runSource(ws, run),
+		CreatedAt:   run.CreatedAt,
+		IsDestroy:   run.IsDestroy,
+		PlanOnly:    run.PlanOnly,
+		HasChanges:  run.HasChanges,
+	}
+	if run.CreatedBy != nil {
+		hr.CreatedBy = run.CreatedBy.Username
+	}
+
+	ts := run.StatusTimestamps
+	if ts == nil {
+		return hr
+	}
+	planned := ts.PlannedAt
+	if planned.IsZero() {
+		planned = ts.PlannedAndFinishedAt
+	}
+	finished := firstTime(ts.AppliedAt, ts.PlannedAndFinishedAt, ts.ErroredAt, ts.DiscardedAt, ts.CanceledAt, ts.ForceCanceledAt)
+
+	hr.QueuedSeconds = secondsBetween(run.CreatedAt, ts.PlanningAt)
+	hr.PlanningSeconds = secondsBetween(ts.PlanningAt, planned)
+	hr.ApplyingSeconds = secondsBetween(ts.ApplyingAt, ts.AppliedAt)
+	hr.TotalSeconds = secondsBetween(run.CreatedAt, finished)
+	if !ts.AppliedAt.IsZero() {
+		hr.AppliedAt = &ts.AppliedAt
+		if run.AutoApply {
+			hr.AppliedBy = appliedByAutoApply
+		}
+	}
+	return hr
+}
+
+// The earliest of the times which are set, or zero
+func firstTime(times ...time.Time) time.Time {
+	var first time.Time
+	for _, t := range times {
+		if !t.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	return first
+}
+
+func secondsBetween(from, to time.Time) *int {
+	if from.IsZero() || to.IsZero() {
+		return nil
+	}
+	s := int(to.Sub(from).Seconds())
+	return &s
+}
+
+// Up to the last Runs of the Workspace, newest first, with who created them
+func (c *Client) getLastRuns(ctx context.Context, workspaceID string, last int) ([]*tfe.Run, error) {
+	var runs []*tfe.Run
+
+	n := 0
+	for {
+		opts := &tfe.RunListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+				PageSize:   min(last, 100),
+			},
+			Include: []tfe.RunIncludeOpt{tfe.RunCreatedBy},
+		}
+
+		runList, err := c.Runs.List(ctx, workspaceID, opts)
+		if err != nil {
+			return runs, err
+		}
+
+		for _, run := range runList.Items {
+			runs = append(runs, run)
+			if len(runs) == last {
+				return runs, nil
+			}
+		}
+
+		if runList.NextPage > n {
+			n = runList.NextPage
+		} else {
+			return runs, nil
+		}
+	}
+}
+
+// The username of whoever confirmed the Run; go-tfe v1.10.0 doesn't model the relationship, so it's read raw, and
+// usernames are cached by user ID
+func (c *Client) getConfirmedBy(ctx context.Context, run *tfe.Run, usernames map[string]string) (string, error) {
+	r, err := c.getRawResource(ctx, fmt.Sprintf("runs/%s", url.PathEscape(run.ID)))
+	if err != nil {
+		return "", err
+	}
+	userID := r.related("confirmed-by")
+	if userID == "" {
+		return "", nil
+	}
+
+	if username, ok := usernames[userID]; ok {
+		return username, nil
+	}
+	attrs, err := c.getRawAttributes(ctx, fmt.Sprintf("users/%s", url.PathEscape(userID)))
+	if err != nil {
+		return "", err
+	}
+	username, _ := attrs["username"].(string)
+	usernames[userID] = username
+	return username, nil
+}
+
+// One row per Run
+func (e *HistoryExport) CSV() (string, error) {
+	var b strings.Builder
+
+	w := csv.NewWriter(&b)
+	if err := w.Write(HISTORY_CSV_HEADER); err != nil {
+		return "", err
+	}
+
+	for _, hr := range e.Runs {
+		var appliedAt string
+		if hr.AppliedAt != nil {
+			appliedAt = timestamp(*hr.AppliedAt)
+		}
+		record := []string{
+			hr.WorkspaceID, hr.Workspace, hr.RunID, string(hr.Status), hr.Source, timestamp(hr.CreatedAt), hr.CreatedBy,
+			strconv.FormatBool(hr.IsDestroy), strconv.FormatBool(hr.PlanOnly), strconv.FormatBool(hr.HasChanges),
+			secondsString(hr.QueuedSeconds), secondsString(hr.PlanningSeconds), secondsString(hr.ApplyingSeconds),
+			secondsString(hr.TotalSeconds), appliedAt, hr.AppliedBy,
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+func secondsString(s *int) string {
+	if s == nil {
+		return ""
+	}
+	return strconv.Itoa(*s)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// What -exec is templated with, and given as JSON on stdin
+type HookEvent struct {
+	Action    string        `json:"action"`
+	BatchID   string        `json:"batchID"`
+	Workspace HookWorkspace `json:"workspace"`
+	Run       HookRun       `json:"run"`
+}
+
+type HookWorkspace struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Organization string   `json:"organization"`
+	Tags         []string `json:"tags"`
+}
+
+type HookRun struct {
+	ID      string `json:"id,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Called after every mutation of a Workspace or its Runs
+func (c *Client) acted(ctx context.Context, operation string, ws *tfe.Workspace, run *tfe.Run) {
+	if err := c.ledger.record(operation, ws, run); err != nil {
+		slog.Error("unable to record in ledger", "workspace", ws.Name, "error", err)
+	}
+	c.touched[ws.ID] = true
+	c.changed[ws.ID] = true
+	c.outcomes[operation]++
+
+	args := []any{"operation", operation, "workspace", ws.Name, "batchID", c.batchID}
+	if run != nil {
+		args = append(args, "runID", run.ID)
+	}
+	slog.Debug("acted", args...)
+
+	c.hook(ctx, operation, ws, run)
+}
+
+// Run the -exec command for a Workspace which was acted on; failures are logged rather than stopping the batch
+func (c *Client) hook(ctx context.Context, action string, ws *tfe.Workspace, run *tfe.Run) {
+	if c.exec == nil {
+		return
+	}
+
+	event := HookEvent{
+		Action:  action,
+		BatchID: c.batchID,
+		Workspace: HookWorkspace{
+			ID:   ws.ID,
+			Name: ws.Name,
+			Tags: append([]string{}, ws.TagNames...),
+		},
+	}
+	if ws.Organization != nil {
+		event.Workspace.Organization = ws.Organization.Name
+	}
+	if run != nil {
+		event.Run = HookRun{
+			ID:      run.ID,
+			Status:  string(run.Status),
+			Message: run.Message,
+		}
+	}
+
+	// Each argument is rendered on its own and no shell sees them, so run messages from commits can't inject commands
+	argv := make([]string, len(c.exec))
+	for idx, tmpl := range c.exec {
+		var arg strings.Builder
+		if err := tmpl.Execute(&arg, event); err != nil {
+			slog.Error("exec template failed", "workspace", ws.Name, "error", err)
+			return
+		}
+		argv[idx] = arg.String()
+	}
+
+	stdin, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("exec failed", "workspace", ws.Name, "error", err)
+		return
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), event.environ()...)
+
+	slog.Info("exec", "workspace", ws.Name, "command", argv)
+	if err := cmd.Run(); err != nil {
+		slog.Error("exec failed", "workspace", ws.Name, "error", err)
+	}
+}
+
+// The event as GO_TFE_BULK_* environment variables, for commands which don't read stdin
+func (e HookEvent) environ() []string {
+	return []string{
+		"GO_TFE_BULK_ACTION=" + e.Action,
+		"GO_TFE_BULK_BATCH_ID=" + e.BatchID,
+		"GO_TFE_BULK_WORKSPACE_ID=" + e.Workspace.ID,
+		"GO_TFE_BULK_WORKSPACE_NAME=" + e.Workspace.Name,
+		"GO_TFE_BULK_ORGANIZATION=" + e.Workspace.Organization,
+		"GO_TFE_BULK_WORKSPACE_TAGS=" + strings.Join(e.Workspace.Tags, ","),
+		"GO_TFE_BULK_RUN_ID=" + e.Run.ID,
+		"GO_TFE_BULK_RUN_STATUS=" + e.Run.Status,
+		"GO_TFE_BULK_RUN_MESSAGE=" + e.Run.Message,
+	}
+}
+
+// A template for each argument of the -exec command, split on whitespace outside quotes and {{ }} actions the way
+// a shell would split words, but without any of a shell's expansions
+func parseExecTemplate(command string) ([]*template.Template, error) {
+	words, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, errors.New("no command")
+	}
+
+	argv := make([]*template.Template, len(words))
+	for idx, word := range words {
+		if argv[idx], err = template.New(fmt.Sprintf("exec[%d]", idx)).Parse(word); err != nil {
+			return nil, err
+		}
+	}
+	return argv, nil
+}
+
+func splitCommand(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		ch := command[i]
+		switch {
+		case strings.HasPrefix(command[i:], "{{"):
+			end := strings.Index(command[i:], "}}")
+			if end < 0 {
+				return nil, errors.New("unterminated {{")
+			}
+			word.WriteString(command[i : i+end+2])
+			i += end + 1
+			inWord = true
+		case ch == '\\' && i+1 < len(command):
+			i++
+			word.WriteByte(command[i])
+			inWord = true
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(command[i+1:], ch)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %c", ch)
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{command: "", want: nil},
+		{command: "notify", want: []string{"notify"}},
+		{command: "  notify   --channel\tops\n", want: []string{"notify", "--channel", "ops"}},
+		{command: `notify 'two words' "and more"`, want: []string{"notify", "two words", "and more"}},
+		{command: `notify pre'quoted'post`, want: []string{"notify", "prequotedpost"}},
+		{command: `notify ''`, want: []string{"notify", ""}},
+		{command: `notify a\ b \"c\"`, want: []string{"notify", "a b", `"c"`}},
+		{command: `notify "it's"`, want: []string{"notify", "it's"}},
+		{command: `notify {{ .Workspace.Name }} {{ printf "%s %s" .Action .BatchID }}`, want: []string{"notify", "{{ .Workspace.Name }}", `{{ printf "%s %s" .Action .BatchID }}`}},
+		{command: `notify ws={{ .Workspace.Name }}`, want: []string{"notify", "ws={{ .Workspace.Name }}"}},
+		{command: "notify $(rm -rf /) ; echo", want: []string{"notify", "$(rm", "-rf", "/)", ";", "echo"}},
+		{command: `notify 'unterminated`, wantErr: true},
+		{command: `notify "unterminated`, wantErr: true},
+		{command: `notify {{ .Action`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, err := splitCommand(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// A run message from a commit is one argument, whatever it contains
+func TestExecTemplateKeepsValuesWhole(t *testing.T) {
+	argv, err := parseExecTemplate(`notify --message {{ .Run.Message }} --workspace={{ .Workspace.Name }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := HookEvent{Workspace: HookWorkspace{Name: "dev"}, Run: HookRun{Message: `fix "quotes"; rm -rf / $(id)`}}
+	var got []string
+	for _, tmpl := range argv {
+		var arg strings.Builder
+		if err := tmpl.Execute(&arg, event); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, arg.String())
+	}
+
+	want := []string{"notify", "--message", `fix "quotes"; rm -rf / $(id)`, "--workspace=dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rendered %q, want %q", got, want)
+	}
+}
+
+func TestParseExecTemplateRefusesEmpty(t *testing.T) {
+	for _, command := range []string{"", "   "} {
+		if _, err := parseExecTemplate(command); err == nil {
+			t.Errorf("parseExecTemplate(%q) accepted no command", command)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Local record of the Workspaces each batch has acted on, so re-running a batch skips them
+type Ledger struct {
+	file    *os.File
+	action  string
+	batchID string
+	done    map[ledgerKey]bool
+}
+
+type ledgerKey struct {
+	action      string
+	workspaceID string
+}
+
+// One line of the ledger file
+type LedgerEntry struct {
+	BatchID     string    `json:"batchID"`
+	Action      string    `json:"action"`
+	WorkspaceID string    `json:"workspaceID"`
+	Workspace   string    `json:"workspace"`
+	Operation   string    `json:"operation"`
+	RunID       string    `json:"runID,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// Load the entries for this batch, and open the file for appending new ones
+func openLedger(path, action, batchID string) (*Ledger, error) {
+	if batchID == "" {
+		return nil, errors.New("-ledger requires -batch-id")
+	}
+
+	l := &Ledger{
+		action:  action,
+		batchID: batchID,
+		done:    map[ledgerKey]bool{},
+	}
+
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for line := 1; scanner.Scan(); line++ {
+			entry := LedgerEntry{}
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			if entry.BatchID == batchID {
+				l.done[ledgerKey{entry.Action, entry.WorkspaceID}] = true
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l.file = f
+
+	return l, nil
+}
+
+// Whether the Workspace was already acted on by this action of the batch; a nil Ledger has processed nothing
+func (l *Ledger) processed(workspaceID string) bool {
+	return l != nil && l.done[ledgerKey{l.action, workspaceID}]
+}
+
+// Record and check entries for another action of a composite -action
+func (l *Ledger) use(action string) {
+	if l != nil {
+		l.action = action
+	}
+}
+
+func (l *Ledger) record(operation string, ws *tfe.Workspace, run *tfe.Run) error {
+	if l == nil {
+		return nil
+	}
+
+	entry := LedgerEntry{
+		BatchID:     l.batchID,
+		Action:      l.action,
+		WorkspaceID: ws.ID,
+		Workspace:   ws.Name,
+		Operation:   operation,
+		At:          time.Now().UTC(),
+	}
+	if run != nil {
+		entry.RunID = run.ID
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+
+	l.done[ledgerKey{l.action, ws.ID}] = true
+	return nil
+}
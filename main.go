@@ -1,89 +1,867 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
+	"text/template"
 	"time"
 
 	tfe "github.com/hashicorp/go-tfe"
 	"golang.org/x/exp/slices"
 )
 
-var ACTIONS = []string{"run", "confirm", "discard", "cancel", "cleanup", "echo"}
+// Actions which can't be combined with others in a composite -action
+var SINGLE_ACTIONS = []string{"diff-snapshots", "fixtures", "tui", "serve"}
+
+// Actions on the current Run, which skip Workspaces without one; every other action also selects those
+var RUN_ACTIONS = []string{"confirm", "discard", "cancel", "cleanup", "expire", "supersede", "replan", "comment", "export-timeline", "sentinel-mocks", "tui", "echo"}
+
+var ACTIONS = []string{"run", "confirm", "apply", "discard", "cancel", "cleanup", "expire", "supersede", "replan", "comment", "digest", "snapshot", "diff-snapshots", "fixtures", "export-compliance", "export-costs", "export-timeline", "history", "apply-logs", "export-outputs", "output-audit", "manifest-diff", "run-sources", "team-audit", "sentinel-mocks", "maintenance", "mute", "unmute", "archive", "unarchive", "settings", "auto-apply-run-trigger", "auto-destroy", "align-defaults", "migrate-remote", "rewire-vcs", "retarget-branch", "tag-audit", "var-set", "var-import", "var-report", "var-precedence", "sensitive-audit", "varset-sync", "validate", "whoami", "branch-check", "tui", "serve", "select", "echo"}
+
+// Statuses where a Run is waiting on something (another Run, a human) rather than doing work
+var WAITING_STATUSES = []tfe.RunStatus{
+	tfe.RunPending,
+	tfe.RunPlanQueued,
+	tfe.RunPlanned,
+	tfe.RunCostEstimated,
+	tfe.RunPolicyChecked,
+	tfe.RunPolicyOverride,
+	tfe.RunPolicySoftFailed,
+	tfe.RunPostPlanCompleted,
+}
+
+// Statuses where a Run has a plan which could still be applied
+var PLANNED_STATUSES = []tfe.RunStatus{
+	tfe.RunPlanned,
+	tfe.RunCostEstimated,
+	tfe.RunPolicyChecked,
+	tfe.RunPolicyOverride,
+	tfe.RunPolicySoftFailed,
+	tfe.RunPostPlanCompleted,
+}
 
 type Client struct {
 	*tfe.Client
+
+	// Command run for every Workspace acted on, if any
+	exec []*template.Template
+	// Applied to JSON reports, if any
+	query string
+	// Detected at startup
+	platform *Platform
+	// Sends requests go-tfe doesn't make itself through the same replay, record, debug and read-only transports
+	httpClient *http.Client
+	// Falls back through the configured tokens, if there are several
+	fallback *tokenFallback
+	// Workspaces already processed by this batch, if any
+	ledger *Ledger
+	// Keeps Runs under the organization's concurrency, if enabled
+	throttler *Throttle
+	// Added to the message of Runs created and the comment of Runs confirmed
+	annotation string
+	// Identifies this invocation in Run messages, logs and reports, the -batch-id or generated
+	batchID string
+	// Only act on Runs queued by this earlier batch, with -from-batch
+	fromBatch string
+	// The kind of token in use, from -token-type or read once it's needed
+	tokenType string
+	// The action being run, checked against the rules
+	action string
+	// Local constraints on what the action may touch, if any
+	rules *Rules
+	// Conditions every Run must meet before it's confirmed, if any
+	checklist *Checklist
+	// The statuses Runs may be confirmed, discarded or canceled from, by operation, from the config
+	actionable map[string][]tfe.RunStatus
+	// The order Workspaces are processed in, as listed if empty
+	sortBy  string
+	reverse bool
+	// Take Workspaces from each project or tag value in turn, so none monopolizes the queue, if set
+	interleave string
+	// Process Workspaces by project or tag value, one group after another, if set
+	groupBy    string
+	waitGroups bool
+	// What a failed Run does to later groups, GateStop or GateSkipDownstream, if set
+	gate string
+	// Process Workspaces this many at a time with a pause between, if set
+	batchSize  int
+	batchPause time.Duration
+	// Workspaces listed by the first action, shared by the rest of a composite -action
+	selection []*tfe.Workspace
+	// Runs given with -run-ids to act on instead of discovering Workspaces, if any
+	runIDs []string
+	// Names or IDs given with -workspace-file, the only Workspaces matching the search to act on, if any
+	workspaces map[string]bool
+	// Workspaces acted on since they were listed
+	touched map[string]bool
+	// Workspaces acted on by any action, for -report-untouched
+	changed map[string]bool
+	// How many times each operation was done, for -expect
+	outcomes map[string]int
+	// Configuration Versions to start Runs from instead of the latest, by Workspace ID, with -commit-sha
+	pinned map[string]*tfe.ConfigurationVersion
+	// Runs canceled or discarded in the batch, for -receipt-file
+	receipt []*ReceiptRun
+	// Never cancel or discard Runs this tool queued, with -exclude-own-runs
+	excludeOwn bool
+	// Estimate how long a batch takes before confirming it, with -estimate
+	estimate bool
+	// Runs started by the run action apply without confirmation, whatever the Workspace's setting, with -auto-apply-run
+	autoApply bool
+	// Missing permissions already warned about
+	permissions *PermissionCache
+	// Kinds of error to skip Workspaces over rather than fail, and how many were
+	skipErrors map[string]bool
+	skipped    map[string]int
+	// Holds the batch between Workspaces while an operator has it paused
+	pauser *Pauser
 }
 
-func main() {
-	token := os.Getenv("TFE_TOKEN")
-	if token == "" {
-		fmt.Println("Environment variable 'TFE_TOKEN' not found")
-		os.Exit(1)
-	}
+// Settings which apply to every action
+type clientOptions struct {
+	exec       string
+	query      string
+	action     string
+	ledger     string
+	batchID    string
+	fromBatch  string
+	tokenType  string
+	rules      string
+	checklist  string
+	policy     string
+	approval   string
+	record     string
+	replay     string
+	debug      bool
+	retries    int
+	sortBy     string
+	reverse    bool
+	groupBy    string
+	interleave string
+	waitGroups bool
+	gate       string
+	batchSize  int
+	batchPause time.Duration
+	skipErrors []string
+	runIDs     []string
+	workspaces map[string]bool
+	excludeOwn bool
+	estimate   bool
+	readOnly   bool
+	actionable map[string][]tfe.RunStatus
+	autoApply  bool
+	permCache  string
+	permTTL    time.Duration
+}
+
+// A Run selected for an action, along with the Workspace it belongs to
+type target struct {
+	ws  *tfe.Workspace
+	run *tfe.Run
+}
 
+func main() {
 	org := flag.String("org", "", "Terraform Cloud organization name (required)")
 	search := flag.String("search", "", "Workspace search (optional)")
-	action := flag.String("action", "", "Action to do on the Workspace(s) [run|confirm|discard|cancel|cleanup|echo] (required)")
+	runIDsFlag := flag.String("run-ids", "", fmt.Sprintf("Act on these Runs, separated by commas or read from @FILE, instead of searching for Workspace(s) [%s] (optional)", strings.Join(RUN_ID_ACTIONS, "|")))
+	workspaceFileFlag := flag.String("workspace-file", "", "Only act on the Workspace(s) matching the search which this file names or identifies, one per line or the JSON of select (optional)")
+	action := flag.String("action", "", fmt.Sprintf("Action to do on the Workspace(s), several may be given separated by commas [%s] (required)", strings.Join(ACTIONS, "|")))
 	assume := flag.Bool("assume-yes", false, "Run without prompting for confirmation (optional)")
+	commit := flag.String("commit-sha", "", "Only cancel or discard Runs created from this commit, full or abbreviated, including ones queued behind the current Run; or start Runs pinned to it (optional; for run, cancel and discard)")
+	configVersion := flag.String("config-version", "", "Only act on Runs created from this Configuration Version ID, including ones queued behind the current Run (optional; for cancel and discard)")
+	excludeOwnRuns := flag.Bool("exclude-own-runs", false, "Never cancel or discard Runs queued by this tool, e.g. by an earlier batch still in flight (optional; for cancel, discard, cleanup, expire, supersede and replan)")
 	stuckStatus := flag.String("stuck-status", "cost_estimated", "Where the Run waits for confirmation (optional; for cleanup only)")
+	discardPolicyFailed := flag.Bool("discard-policy-failed", false, "Also discard current Runs a hard-mandatory policy failed, which can never be applied (optional; for cleanup only)")
+	fromVCS := flag.String("from-vcs", "", "OAuth token (ot-) or GitHub App installation (ghain-) ID the Workspace(s) are connected through (required; for rewire-vcs only)")
+	toVCS := flag.String("to-vcs", "", "OAuth token (ot-) or GitHub App installation (ghain-) ID to connect them through instead (required; for rewire-vcs only)")
+	fromBranch := flag.String("from-branch", "", "VCS branch the Workspace(s) track, e.g. master (required; for retarget-branch only)")
+	toBranch := flag.String("to-branch", "", "VCS branch to track instead, e.g. main (required; for retarget-branch only)")
+	verifyPlan := flag.Bool("verify-plan", false, "Queue a plan-only Run on each Workspace migrated to remote execution or retargeted (optional; for migrate-remote and retarget-branch)")
+	requeue := flag.Bool("requeue", false, "Queue a fresh Run in place of each Run discarded by -discard-policy-failed (optional; for cleanup only)")
 	erroredOnly := flag.Bool("errored-only", false, "Only attempt the action if the current Run has Errored (optional; for run only)")
+	forceDuplicate := flag.Bool("force-duplicate", false, "Start a Run even if an identical one is already waiting (optional; for run only)")
+	agentWaves := flag.Bool("agent-waves", true, "Start Runs on agent pools in waves no larger than the pool's idle agents (optional; for run only)")
+	checkIngress := flag.Bool("check-ingress", false, "Skip VCS-driven Workspace(s) whose latest configuration ingress failed, as their Runs would error fetching it (optional; for run only)")
+	requireAgents := flag.Int("require-agents", 0, "Abort unless every agent pool involved has at least this many connected agents (optional; for run and confirm)")
+	throttle := flag.Bool("throttle", false, "Wait for the organization's run queue to have room before starting or confirming each Run (optional; for run and confirm)")
+	maxConcurrency := flag.Int("max-concurrency", 0, "Run concurrency to throttle to, detected from the organization's subscription if not set (optional)")
+	headroom := flag.Int("headroom", 1, "Run slots the throttle leaves free for interactive users (optional)")
+	olderThan := flag.Duration("older-than", 24*time.Hour, "Minimum age of a waiting Run before it is removed (optional; for expire and -admin cleanup)")
+	staleAfter := flag.Duration("stale-after", 90*24*time.Hour, "Flag branches without an ingressed commit for this long (optional; for branch-check only)")
+	last := flag.Int("last", 20, "How many of each Workspace's most recent Runs to export (optional; for history only)")
+	since := flag.Duration("since", 7*24*time.Hour, "How far back to summarize (optional; for digest, run-sources and apply-logs without -run-ids)")
+	reportFile := flag.String("report-file", "", "Write the report to this file instead of stdout (optional; for digest, snapshot, diff-snapshots, export-compliance, export-costs, export-timeline, history, run-sources, var-report, var-precedence, sensitive-audit, tag-audit, output-audit, manifest-diff, team-audit, select and confirm with -checklist)")
+	format := flag.String("format", "json", "Report format: json or csv for export-compliance and history, json, dotenv or tfvars for export-outputs, json or text for select (optional)")
+	logDir := flag.String("log-dir", "apply-logs", "Directory to write each Workspace's failed apply logs to (optional; for apply-logs only)")
+	tailLines := flag.Int("tail-lines", 100, "How many of the last lines of each failed apply's log to collect (optional; for apply-logs only)")
+	outputDir := flag.String("output-dir", "outputs", "Directory to write each Workspace's outputs to (optional; for export-outputs only)")
+	reason := flag.String("reason", "Maintenance", "Lock reason shown on the Workspace(s) (optional; for maintenance only)")
+	cancelInFlight := flag.Bool("cancel-in-flight", false, "Cancel in-flight Runs instead of waiting for them to finish (optional; for maintenance only)")
+	outputContract := flag.String("output-contract", "", "JSON file of the outputs every Workspace's state must expose, and may also expose (required; for output-audit only)")
+	manifestFile := flag.String("manifest-file", "", "YAML file of the settings each named Workspace should have, or JSON with a .json extension (required; for manifest-diff only)")
+	taxonomyFile := flag.String("taxonomy", "", "JSON file of the tag keys every Workspace must have and the values allowed (required; for tag-audit only)")
+	tagLookup := flag.String("tag-lookup", "", "JSON file of missing tags to add, by Workspace name, e.g. '{\"billing-prod\": {\"owner\": \"payments\"}}' (optional; for tag-audit only)")
+	mockDir := flag.String("mock-dir", "mocks", "Directory to download Sentinel mocks into, one directory per Workspace and Run (optional; for sentinel-mocks only)")
+	muteFile := flag.String("mute-file", "muted.json", "Where mute records the notification configuration(s) it disabled (optional; for mute and unmute)")
+	end := flag.Bool("end", false, "End the maintenance window, unlocking the Workspace(s) it locked (optional; for maintenance only)")
+	stateFile := flag.String("state-file", "maintenance.json", "Where the maintenance window records the Workspace(s) it locked (optional; for maintenance only)")
+	commentBody := flag.String("comment-body", "", "Comment to post on the current Run, e.g. 'Paused pending CAB approval CHG-1234' (required; for comment only)")
+	destroyAt := flag.String("destroy-at", "", "When to destroy the Workspace, as a time like 2024-01-31T18:00:00Z or a duration from now like 72h, or 'off' to clear (for auto-destroy only)")
+	destroyInactive := flag.String("destroy-inactive", "", "Destroy the Workspace after this long without activity, e.g. 14d, or 'off' to clear (for auto-destroy only)")
+	triggerAutoApply := flag.String("trigger-auto-apply", "", "Whether Runs queued by run triggers are auto-applied, true or false, separately from -settings auto-apply (required; for auto-apply-run-trigger only)")
+	settingsFlag := flag.String("settings", "", fmt.Sprintf("Workspace settings to change, e.g. 'assessments-enabled=true,auto-destroy-activity-duration=14d' [%s] (required; for settings only)", strings.Join(settingNames(), "|")))
+	varKey := flag.String("var-key", "", "Variable key (required; for var-set and var-report, optional for var-precedence)")
+	varValue := flag.String("var-value", "", "Variable value, a Go template over the Workspace e.g. '{{ .Name }}-state' (optional; for var-set only)")
+	varCategory := flag.String("var-category", "terraform", "Variable category [terraform|env] (optional; for var-set only)")
+	varHCL := flag.Bool("var-hcl", false, "Parse the Variable value as HCL (optional; for var-set only)")
+	varSensitive := flag.Bool("var-sensitive", false, "Mark the Variable as sensitive (optional; for var-set only)")
+	sensitivePatterns := flag.String("sensitive-patterns", DEFAULT_SENSITIVE_PATTERNS, "Variable keys which should be sensitive, as case-insensitive glob patterns (optional; for sensitive-audit only)")
+	fixSensitive := flag.Bool("fix-sensitive", false, "Rewrite the Variables found as sensitive, which can't be undone (optional; for sensitive-audit only)")
+	variableSet := flag.String("variable-set", "", "Name of the Variable Set to sync -var-file to (required; for varset-sync only)")
+	varFile := flag.String("var-file", "", "JSON file listing Variables to set, or YAML with a .yaml or .yml extension for varset-sync (required; for var-import and varset-sync)")
+	execCmd := flag.String("exec", "", "Command run for every Workspace acted on, without a shell, each argument templated with {{.Action}}, {{.Workspace.Name}}, {{.Run.ID}} etc. and given the same as JSON on stdin and GO_TFE_BULK_* environment variables (optional)")
+	ledger := flag.String("ledger", "", "File recording the Workspace(s) each batch has acted on, so re-running a batch skips them (optional; requires -batch-id)")
+	simulatePermissions := flag.Bool("simulate-permissions", false, "Report which Workspace(s) the token has the permissions for the action on, without doing it (optional)")
+	record := flag.String("record", "", "Write every API request and response, with tokens redacted, to this cassette file (optional)")
+	replay := flag.String("replay", "", "Answer every API request from this cassette file instead of the API, TFE_TOKEN is not needed (optional)")
+	debugHTTP := flag.Bool("debug-http", false, "Log every API request and response, with tokens and sensitive values redacted (optional)")
+	sortBy := flag.String("sort", "", fmt.Sprintf("Order to process the Workspace(s) in [%s] (optional)", strings.Join(SORT_KEYS, "|")))
+	reverse := flag.Bool("reverse", false, "Reverse the -sort order (optional)")
+	groupBy := flag.String("group-by", "", "Process the Workspace(s) in groups, one after another [project|tag:KEY] (optional; for run and confirm)")
+	interleaveBy := flag.String("interleave-by", "", "Process the Workspace(s) of each project or tag value in turn rather than in -sort order, so no team's Runs take up the queue [project|tag:KEY] (optional)")
+	waitGroups := flag.Bool("wait-groups", false, "Wait for each group's Runs to finish before starting the next (optional; for -group-by)")
+	gate := flag.String("gate", "", "Wait for each group's Runs and, if any fail, stop or skip the Workspace(s) they run-trigger in later groups [on-failure=stop|on-failure=skip-downstream] (optional; for -group-by, and apply which only stops)")
+	checkpointFile := flag.String("checkpoint", "pipeline.json", "Where apply records each Workspace's progress, an existing one is resumed (optional; for apply only)")
+	planRetries := flag.Int("plan-retries", 0, "Times apply starts a Run again after it fails to plan (optional; for apply only)")
+	parallel := flag.Int("parallel", 0, "Workspaces apply has Runs in flight for at once, unlimited if 0 (optional; for apply only)")
+	batchSize := flag.Int("batch-size", 0, "Process this many Workspace(s) at a time (optional; for run and confirm)")
+	batchPause := flag.Duration("batch-pause", 0, "Pause between batches of -batch-size (optional)")
+	minRunAge := flag.Duration("min-run-age", 0, "Only confirm Runs planned at least this long ago, leaving a window for review (optional; for confirm and tui)")
+	allowStale := flag.Bool("allow-stale", false, "Confirm Runs even if their Configuration Version is outdated or a newer Run exists (optional; for confirm, apply and tui)")
+	conflictRetries := flag.Int("conflict-retries", 5, "Times to retry a change which conflicts with the Workspace's lock or Runs, with backoff (optional)")
+	permissionCache := flag.String("permission-cache", "", "File remembering missing permissions already warned about, so later invocations don't repeat them (optional)")
+	permissionCacheTTL := flag.Duration("permission-cache-ttl", 24*time.Hour, "How long a missing permission in -permission-cache isn't warned about again (optional)")
+	skipErrors := flag.String("skip-errors", "", fmt.Sprintf("Kinds of error to quietly skip Workspace(s) over, separated by commas [%s] (optional)", strings.Join(SKIPPABLE_ERRORS, "|")))
+	reuseConfig := flag.Bool("reuse-config-version", false, "Start the Runs from each Workspace's latest ingressed Configuration Version rather than fetching it from VCS again, e.g. while the VCS provider is degraded (optional; for run only)")
+	autoApplyRun := flag.Bool("auto-apply-run", false, "Apply the Runs started without confirmation, leaving the Workspace's auto-apply setting alone (optional; for run only)")
+	readOnly := flag.Bool("read-only", false, "Refuse every API call which could change anything, whatever the action (optional)")
+	estimate := flag.Bool("estimate", false, "Estimate how long the batch will take from recent plan and apply durations, shown in the confirmation prompt (optional; for run, confirm and apply)")
+	explain := flag.Bool("explain", false, "Print every decision made on each Workspace, from the filters it matched to the checks it passed or failed, before the confirmation prompt (optional)")
+	reportUntouched := flag.Bool("report-untouched", false, "At the end, list the Workspace(s) matching -org and -search which weren't acted on, with the last decision made on each (optional)")
+	maxRuntime := flag.Duration("max-runtime", 0, fmt.Sprintf("Stop starting operations after this long, leaving a minute for those in flight, then exit %d (optional)", EXIT_MAX_RUNTIME))
+	lockSpec := flag.String("lock", "", "Hold a lock for the batch so no other can run against the organization at once, file:PATH or workspace:NAME to keep it in a variable of that workspace (optional)")
+	lockTTL := flag.Duration("lock-ttl", time.Hour, "How long before the -lock of a batch which didn't release it can be taken over (optional)")
+	receiptFile := flag.String("receipt-file", "receipt.json", "Where the Runs canceled or discarded are listed with their prior statuses and commands to start them again, if there were any (optional)")
+	remainingFile := flag.String("remaining-file", "remaining.json", "Where -max-runtime writes the Workspace(s) not yet acted on, for -workspace-file (optional)")
+	quorum := flag.Int("quorum", 0, "Approvals from this many distinct operators are needed to confirm a batch, even with -assume-yes (optional; requires -batch-id, -quorum-keys and -quorum-approvals)")
+	quorumThreshold := flag.Int("quorum-threshold", 0, "Only batches of more changes than this need the quorum (optional; for -quorum)")
+	quorumKeys := flag.String("quorum-keys", "", "Directory of operators' Ed25519 public keys, as <operator>.pem (optional; for -quorum)")
+	quorumApprovals := flag.String("quorum-approvals", "", "Directory of signed approval files, or the URL of an approvals API returning them (optional; for -quorum)")
+	expectFile := flag.String("expect", "", "JSON file of how many times each operation should be done, exit non-zero with a diff if the batch deviates (optional)")
+	eventsFile := flag.String("events", "", "Stream every selection, decision and API result as JSON lines to this file (optional)")
+	configFile := flag.String("config", "", fmt.Sprintf("JSON config file (optional; default %s if it exists)", defaultConfigPath()))
+	rulesFile := flag.String("rules", "", "JSON file of rules denying actions on matching Workspace(s), e.g. confirm on prod outside a change window, or YAML with a .yaml or .yml extension (optional)")
+	admin := flag.Bool("admin", false, fmt.Sprintf("Act across every Organization on a Terraform Enterprise instance through the Admin API, needs a site admin token and -org is not used [%s] (optional)", strings.Join(ADMIN_ACTIONS, "|")))
+	approvalMarker := flag.String("approval-marker", "", "Only confirm Runs with a comment containing this, e.g. posted by a ticket bot (optional; for confirm and apply)")
+	approvalWatch := flag.Duration("approval-watch", 0, "Keep confirming Runs as their approval comments are posted, for up to this long (optional; for confirm with -approval-marker and -assume-yes)")
+	planPolicy := flag.String("plan-policy", "", "Rego policy file evaluated with opa against each plan's JSON, Runs it denies aren't confirmed (optional; for confirm, apply and tui)")
+	checklistFile := flag.String("checklist", "", "JSON file of conditions every Run must meet to be confirmed, e.g. no destroys or a cost delta limit (optional; for confirm and tui)")
+	batchID := flag.String("batch-id", "", "Identifies the batch in the ledger, Run messages, logs and reports, generated if not given (optional)")
+	tokenTypeHint := flag.String("token-type", "", fmt.Sprintf("The kind of token in use, otherwise read from who it authenticates as; team and organization tokens need -org, since they may not list organizations [%s] (optional)", strings.Join(TOKEN_TYPES, "|")))
+	fromBatch := flag.String("from-batch", "", "Only act on Runs queued by the tool in this earlier batch, e.g. to cancel them (optional)")
+	query := flag.String("query", "", "jq-like query applied to JSON reports, e.g. '.workspaces[].name' (optional)")
+	listen := flag.String("listen", ":8080", "Address to listen on for Slack slash commands (optional; for serve only)")
+	serveActions := flag.String("serve-actions", "cleanup,cancel,discard,digest,whoami", "Actions slash commands may run (optional; for serve only)")
+	serveUsers := flag.String("serve-users", "", "Slack user IDs allowed to run slash commands, e.g. U012AB3CD,U045EF6GH (required; for serve only)")
+	notify := flag.String("notify", "", fmt.Sprintf("Notify these channels once the batch finishes or fails, e.g. 'slack=https://hooks.slack.com/...,email=ops@example.com' [%s] (optional)", strings.Join(notifierKinds(), "|")))
+	previewPage := flag.Int("preview", 0, "Before the confirmation prompt, list the changes this many at a time with counts by Project and tag (optional)")
+	webhookURL := flag.String("webhook-url", "", "POST the report as JSON to this URL, e.g. a Slack incoming webhook (optional; for digest only)")
 
 	flag.Parse()
 
-	if *org == "" {
+	// Several actions may be given, done in order on the same Workspace(s)
+	steps := strings.Split(*action, ",")
+	for _, step := range steps {
+		if !slices.Contains(ACTIONS, step) || (len(steps) > 1 && slices.Contains(SINGLE_ACTIONS, step)) {
+			flag.Usage()
+			os.Exit(1)
+		}
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		slog.Error("Unable to read config", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.applyDefaults(steps); err != nil {
+		slog.Error("Unable to apply config defaults", "error", err)
+		os.Exit(1)
+	}
+
+	if *eventsFile != "" {
+		if err := streamEvents(*eventsFile); err != nil {
+			slog.Error("Unable to stream events", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if *explain || *reportUntouched {
+		explainDecisions(*explain)
+	}
+	if *previewPage > 0 {
+		previewChanges(*previewPage)
+	}
+
+	if *quorum > 0 {
+		// -approval-watch confirms a different set of Runs on every poll, and the dashboard one Run at a time, so
+		// neither has a batch to approve
+		if *approvalWatch > 0 || slices.Contains(steps, "tui") {
+			fmt.Println("-quorum can't be used with -approval-watch or -action tui")
+			os.Exit(1)
+		}
+		if err := requireQuorum(*batchID, *quorum, *quorumThreshold, *quorumKeys, *quorumApprovals); err != nil {
+			slog.Error("Unable to require quorum", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	notifiers, err := parseNotifiers(*notify)
+	if err != nil {
+		slog.Error("Unable to set up notifications", "error", err)
+		os.Exit(1)
+	}
+
+	var expected map[string]*Expectation
+	if *expectFile != "" {
+		if expected, err = openExpectations(*expectFile); err != nil {
+			slog.Error("Unable to read expectations", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if (*sortBy != "" && !slices.Contains(SORT_KEYS, *sortBy)) || !validGroupBy(*groupBy) || !validGroupBy(*interleaveBy) || (*groupBy != "" && *interleaveBy != "") || (*gate != "" && (parseGate(*gate) == "" || (*groupBy == "" && !slices.Contains(steps, "apply")))) {
 		flag.Usage()
 		os.Exit(1)
 	}
+	var skipErrorKinds []string
+	if *skipErrors != "" {
+		skipErrorKinds = strings.Split(*skipErrors, ",")
+	}
+	for _, kind := range skipErrorKinds {
+		if !slices.Contains(SKIPPABLE_ERRORS, kind) {
+			flag.Usage()
+			os.Exit(1)
+		}
+	}
 
-	if !slices.Contains(ACTIONS, *action) {
+	var runIDs []string
+	if *runIDsFlag != "" {
+		for _, step := range steps {
+			if !slices.Contains(RUN_ID_ACTIONS, step) {
+				fmt.Printf("-run-ids only supports -action %s\n", strings.Join(RUN_ID_ACTIONS, "|"))
+				os.Exit(1)
+			}
+		}
+		var err error
+		if runIDs, err = parseRunIDs(*runIDsFlag); err != nil {
+			slog.Error("Unable to read run IDs", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var workspaceFile map[string]bool
+	if *workspaceFileFlag != "" {
+		if runIDs != nil {
+			fmt.Println("-workspace-file can't be combined with -run-ids")
+			os.Exit(1)
+		}
+		var err error
+		if workspaceFile, err = openWorkspaceFile(*workspaceFileFlag); err != nil {
+			slog.Error("Unable to read workspace file", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Comparing snapshots and making fixtures are done offline
+	if *action == "diff-snapshots" {
+		if flag.NArg() != 2 {
+			fmt.Println("Usage: -action diff-snapshots <before.json> <after.json>")
+			os.Exit(1)
+		}
+		if err := DiffSnapshots(flag.Arg(0), flag.Arg(1), *reportFile); err != nil {
+			slog.Error("Action failed", "action", *action, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *action == "fixtures" {
+		if flag.NArg() != 2 {
+			fmt.Println("Usage: -action fixtures <cassette.jsonl> <fixture.jsonl>")
+			os.Exit(1)
+		}
+		if err := WriteFixture(flag.Arg(0), flag.Arg(1)); err != nil {
+			slog.Error("Action failed", "action", *action, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The server runs each command as a subprocess, -org only restricts which Organization they can act on
+	if *action == "serve" {
+		if err := Serve(context.Background(), *listen, *org, strings.Split(*serveActions, ","), strings.Split(*serveUsers, ",")); err != nil {
+			slog.Error("Action failed", "action", *action, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *admin {
+		for _, step := range steps {
+			if !slices.Contains(ADMIN_ACTIONS, step) {
+				fmt.Printf("-admin only supports -action %s\n", strings.Join(ADMIN_ACTIONS, "|"))
+				os.Exit(1)
+			}
+		}
+	} else if *org == "" && scopedToken(*tokenTypeHint) {
+		fmt.Printf("-org is required with %s tokens, which may not be allowed to list organizations to discover it\n", *tokenTypeHint)
+		os.Exit(1)
+	}
+	if *tokenTypeHint != "" && !slices.Contains(TOKEN_TYPES, *tokenTypeHint) {
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	client, err := newClient(token)
+	tokens := cfg.tokensFor(tfeAddress(), *org)
+	if len(tokens) == 0 && *replay != "" {
+		tokens = []string{REDACTED}
+	}
+	if len(tokens) == 0 {
+		fmt.Println("Environment variable 'TFE_TOKEN' not found, and no token configured for the organization")
+		os.Exit(1)
+	}
+
+	client, err := newClient(tokens, clientOptions{
+		exec:       *execCmd,
+		query:      *query,
+		action:     steps[0],
+		ledger:     *ledger,
+		batchID:    *batchID,
+		fromBatch:  *fromBatch,
+		tokenType:  *tokenTypeHint,
+		rules:      *rulesFile,
+		checklist:  *checklistFile,
+		policy:     *planPolicy,
+		approval:   *approvalMarker,
+		record:     *record,
+		replay:     *replay,
+		debug:      *debugHTTP,
+		retries:    *conflictRetries,
+		sortBy:     *sortBy,
+		reverse:    *reverse,
+		groupBy:    *groupBy,
+		interleave: *interleaveBy,
+		waitGroups: *waitGroups,
+		gate:       parseGate(*gate),
+		batchSize:  *batchSize,
+		batchPause: *batchPause,
+		skipErrors: skipErrorKinds,
+		runIDs:     runIDs,
+		workspaces: workspaceFile,
+		excludeOwn: *excludeOwnRuns,
+		estimate:   *estimate,
+		readOnly:   *readOnly || cfg.ReadOnly,
+		actionable: cfg.Actionable,
+		autoApply:  *autoApplyRun,
+		permCache:  *permissionCache,
+		permTTL:    *permissionCacheTTL,
+	})
 	if err != nil {
-		slog.Error("Unable to create client", err)
-		return
+		slog.Error("Unable to create client", "error", err)
+		os.Exit(1)
+	}
+	// The dashboard reads its own commands from stdin
+	if stdinIsTerminal() && !slices.Contains(steps, "tui") {
+		client.pauser.listenKeys()
 	}
 
 	ctx := context.Background()
+	if *maxRuntime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = client.limitRuntime(ctx, *maxRuntime)
+		defer cancel()
+	}
+
+	// Without -org, the token's only Organization is used
+	if !*admin && *org == "" {
+		if *org, err = client.discoverOrganization(ctx); err != nil {
+			slog.Error("Unable to discover organization", "error", err)
+			os.Exit(1)
+		}
+	}
+	batchQuorum.setOrganization(*org)
+
+	if *admin {
+		start := time.Now()
+		slog.Info("Running...", "pid", os.Getpid(), "batchID", client.batchID, "admin", true)
+		for _, step := range steps {
+			client.setAction(step)
+			switch step {
+			case "cleanup":
+				err = client.AdminCleanup(ctx, *search, *assume, tfe.RunStatus(*stuckStatus), *olderThan)
+			case "echo":
+				err = client.AdminEcho(ctx, *search, tfe.RunStatus(*stuckStatus))
+			}
+			if *maxRuntime > 0 && maxRuntimeReached(err) {
+				slog.Warn("Max runtime reached", "action", step)
+				os.Exit(EXIT_MAX_RUNTIME)
+			}
+			if err != nil {
+				slog.Error("Action failed", "action", step, "error", err)
+				os.Exit(1)
+			}
+		}
+		client.reportSkipped()
+		client.reportPermissions()
+		slog.Info(fmt.Sprintf("Finished in %fs", time.Since(start).Seconds()))
+		if expected != nil && !client.meetsExpectations(expected) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	client.detectPlatform(ctx, *org)
+
+	if *throttle {
+		if client.throttler, err = client.newThrottle(ctx, *org, *maxConcurrency, *headroom); err != nil {
+			slog.Error("Unable to throttle", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if *simulatePermissions {
+		for _, step := range steps {
+			client.setAction(step)
+			if err := client.SimulatePermissions(ctx, *org, *search, step); err != nil {
+				slog.Error("Simulation failed", "action", step, "error", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	// Read-only batches can't conflict with anything, and couldn't write the lock anyway
+	var lock *heldLock
+	if *lockSpec != "" && !(*readOnly || cfg.ReadOnly) {
+		if lock, err = client.lockFleet(ctx, *lockSpec, *org, steps, *lockTTL); err != nil {
+			slog.Error("Unable to lock", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	origin := RunOrigin{CommitSHA: *commit, ConfigVersionID: *configVersion}
+
+	do := func(step string) error {
+		var err error
+		switch step {
+		case "run":
+			err = client.Run(ctx, *org, *search, *assume, RunOptions{
+				ErroredOnly:    *erroredOnly,
+				ForceDuplicate: *forceDuplicate,
+				AgentWaves:     *agentWaves,
+				CheckIngress:   *checkIngress,
+				ReuseConfig:    *reuseConfig,
+				RequireAgents:  *requireAgents,
+				Commit:         *commit,
+			})
+		case "confirm":
+			if *approvalWatch > 0 {
+				err = client.WatchApprovals(ctx, *assume, *approvalWatch, func() error {
+					return client.Confirm(ctx, *org, *search, *assume, *requireAgents, *minRunAge, *allowStale, *reportFile)
+				})
+			} else {
+				err = client.Confirm(ctx, *org, *search, *assume, *requireAgents, *minRunAge, *allowStale, *reportFile)
+			}
+		case "apply":
+			err = client.Apply(ctx, *org, *search, *assume, *checkpointFile, PipelineOptions{
+				Retries:       *planRetries,
+				StopOnFailure: parseGate(*gate) == GateStop,
+				Parallel:      *parallel,
+				AllowStale:    *allowStale,
+			})
+		case "discard":
+			err = client.Discard(ctx, *org, *search, *assume, origin)
+		case "cancel":
+			err = client.Cancel(ctx, *org, *search, *assume, origin)
+		case "cleanup":
+			err = client.Cleanup(ctx, *org, *search, *assume, tfe.RunStatus(*stuckStatus), *discardPolicyFailed, *requeue)
+		case "expire":
+			err = client.Expire(ctx, *org, *search, *assume, *olderThan)
+		case "supersede":
+			err = client.Supersede(ctx, *org, *search, *assume)
+		case "replan":
+			err = client.Replan(ctx, *org, *search, *assume)
+		case "comment":
+			err = client.Comment(ctx, *org, *search, *assume, *commentBody)
+		case "digest":
+			err = client.Digest(ctx, *org, *search, *since, *reportFile, *webhookURL)
+		case "snapshot":
+			err = client.Snapshot(ctx, *org, *search, *reportFile)
+		case "export-compliance":
+			err = client.ExportCompliance(ctx, *org, *search, *format, *reportFile)
+		case "export-costs":
+			err = client.ExportCosts(ctx, *org, *search, *reportFile)
+		case "export-timeline":
+			err = client.ExportTimeline(ctx, *org, *search, *reportFile)
+		case "history":
+			err = client.History(ctx, *org, *search, *last, *format, *reportFile)
+		case "apply-logs":
+			err = client.ApplyLogs(ctx, *org, *search, *since, *tailLines, *logDir)
+		case "export-outputs":
+			err = client.ExportOutputs(ctx, *org, *search, *format, *outputDir)
+		case "output-audit":
+			err = client.OutputAudit(ctx, *org, *search, *outputContract, *reportFile)
+		case "manifest-diff":
+			err = client.ManifestDiff(ctx, *org, *manifestFile, *reportFile)
+		case "team-audit":
+			err = client.TeamAudit(ctx, *org, *search, *reportFile)
+		case "run-sources":
+			err = client.RunSources(ctx, *org, *search, *since, *reportFile)
+		case "sentinel-mocks":
+			err = client.SentinelMocks(ctx, *org, *search, *mockDir)
+		case "maintenance":
+			if *end {
+				err = client.EndMaintenance(ctx, *assume, *stateFile)
+			} else {
+				err = client.Maintenance(ctx, *org, *search, *assume, *reason, *cancelInFlight, *stateFile)
+			}
+		case "mute":
+			err = client.Mute(ctx, *org, *search, *assume, *muteFile)
+		case "unmute":
+			err = client.Unmute(ctx, *assume, *muteFile)
+		case "archive":
+			err = client.Archive(ctx, *org, *search, *assume)
+		case "unarchive":
+			err = client.Unarchive(ctx, *org, *search, *assume)
+		case "settings":
+			var settings map[string]any
+			if settings, err = parseSettings(*settingsFlag); err == nil {
+				err = client.Settings(ctx, *org, *search, *assume, settings)
+			}
+		case "align-defaults":
+			err = client.AlignDefaults(ctx, *org, *search, *assume)
+		case "rewire-vcs":
+			err = client.RewireVCS(ctx, *org, *search, *assume, *fromVCS, *toVCS)
+		case "retarget-branch":
+			err = client.RetargetBranch(ctx, *org, *search, *assume, *fromBranch, *toBranch, *verifyPlan)
+		case "migrate-remote":
+			err = client.MigrateRemote(ctx, *org, *search, *assume, *verifyPlan)
+		case "tag-audit":
+			err = client.TagAudit(ctx, *org, *search, *assume, *taxonomyFile, *tagLookup, *reportFile)
+		case "auto-apply-run-trigger":
+			err = client.TriggerAutoApply(ctx, *org, *search, *assume, *triggerAutoApply)
+		case "auto-destroy":
+			err = client.AutoDestroy(ctx, *org, *search, *assume, *destroyAt, *destroyInactive)
+		case "var-set":
+			err = client.VarSet(ctx, *org, *search, *assume, VariableSpec{
+				Key:       *varKey,
+				Value:     *varValue,
+				Category:  *varCategory,
+				HCL:       *varHCL,
+				Sensitive: *varSensitive,
+			})
+		case "var-import":
+			err = client.VarImport(ctx, *org, *search, *assume, *varFile)
+		case "var-report":
+			err = client.VarReport(ctx, *org, *search, *varKey, *reportFile)
+		case "varset-sync":
+			err = client.VarSetSync(ctx, *org, *assume, *variableSet, *varFile)
+		case "var-precedence":
+			err = client.VarPrecedence(ctx, *org, *search, *varKey, *reportFile)
+		case "sensitive-audit":
+			err = client.SensitiveAudit(ctx, *org, *search, *assume, *sensitivePatterns, *fixSensitive, *reportFile)
+		case "validate":
+			err = client.Validate(ctx, *org, *search, cfg)
+		case "whoami":
+			err = client.Whoami(ctx, *org, *search)
+		case "branch-check":
+			err = client.BranchCheck(ctx, *org, *search, *staleAfter)
+		case "tui":
+			err = client.Dashboard(ctx, *org, *search, *minRunAge, *allowStale)
+		case "select":
+			err = client.Select(ctx, *org, *search, *format, *reportFile)
+		case "echo":
+			err = client.Echo(ctx, *org, *search)
+		}
+		return err
+	}
 
 	start := time.Now()
-	slog.Info("Running...")
-	switch *action {
-	case "run":
-		client.Run(ctx, *org, *search, *assume, *erroredOnly)
-	case "confirm":
-		client.Confirm(ctx, *org, *search, *assume)
-	case "discard":
-		client.Discard(ctx, *org, *search, *assume)
-	case "cancel":
-		client.Cancel(ctx, *org, *search, *assume)
-	case "cleanup":
-		client.Cleanup(ctx, *org, *search, *assume, tfe.RunStatus(*stuckStatus))
-	case "echo":
-		client.Echo(ctx, *org, *search)
+	slog.Info("Running...", "pid", os.Getpid(), "batchID", client.batchID)
+	for _, step := range steps {
+		client.setAction(step)
+		if len(steps) > 1 {
+			slog.Info("action", "action", step)
+		}
+		if err := do(step); err != nil {
+			err = client.explainTokenError(ctx, step, err)
+			notifyAll(notifiers, client.batchResult(*org, *search, steps, start, err))
+			if err := client.writeReceipt(*receiptFile, *org); err != nil {
+				slog.Error("Unable to write receipt", "error", err)
+			}
+			lock.unlock(context.Background())
+			if *maxRuntime > 0 && maxRuntimeReached(err) {
+				client.reportSkipped()
+				if err := client.writeRemaining(*remainingFile); err != nil {
+					slog.Error("Unable to write remaining Workspace(s)", "error", err)
+				}
+				os.Exit(EXIT_MAX_RUNTIME)
+			}
+			slog.Error("Action failed", "action", step, "error", err)
+			os.Exit(1)
+		}
+		explanation.flush(os.Stdout)
+	}
+	client.reportSkipped()
+	client.reportPermissions()
+	if err := client.writeReceipt(*receiptFile, *org); err != nil {
+		slog.Error("Unable to write receipt", "error", err)
+	}
+	lock.unlock(context.Background())
+	if *reportUntouched {
+		client.reportUntouched()
 	}
 	slog.Info(fmt.Sprintf("Finished in %fs", time.Since(start).Seconds()))
+	notifyAll(notifiers, client.batchResult(*org, *search, steps, start, nil))
+	if expected != nil && !client.meetsExpectations(expected) {
+		os.Exit(1)
+	}
 }
 
-func newClient(token string) (*Client, error) {
+func newClient(tokens []string, opts clientOptions) (*Client, error) {
 	config := &tfe.Config{
-		Token: token,
+		Token: tokens[0],
+	}
+
+	var transport http.RoundTripper = http.DefaultTransport
+	var fallback *tokenFallback
+	if opts.replay != "" {
+		rep, err := newReplayer(opts.replay)
+		if err != nil {
+			return &Client{}, err
+		}
+		transport = rep
+	} else if len(tokens) > 1 {
+		fallback = &tokenFallback{next: transport, tokens: tokens}
+		transport = fallback
+	}
+	if opts.record != "" {
+		rec, err := newRecorder(opts.record, transport)
+		if err != nil {
+			return &Client{}, err
+		}
+		transport = rec
+	}
+	if opts.debug {
+		transport = &debugTransport{next: transport}
+	}
+	if opts.retries > 0 {
+		transport = &conflictRetry{next: transport, retries: opts.retries}
 	}
+	transport = newWorkspaceFence(transport)
+	if opts.readOnly {
+		transport = &readOnlyTransport{next: transport}
+	}
+	config.HTTPClient = &http.Client{Transport: transport}
 
 	client, err := tfe.NewClient(config)
 	if err != nil {
 		return &Client{}, err
 	}
 
-	return &Client{client}, nil
+	c := &Client{
+		Client:     client,
+		httpClient: config.HTTPClient,
+		fallback:   fallback,
+		query:      opts.query,
+		batchID:    opts.batchID,
+		fromBatch:  opts.fromBatch,
+		tokenType:  opts.tokenType,
+		action:     opts.action,
+		sortBy:     opts.sortBy,
+		reverse:    opts.reverse,
+		groupBy:    opts.groupBy,
+		interleave: opts.interleave,
+		waitGroups: opts.waitGroups,
+		gate:       opts.gate,
+		batchSize:  opts.batchSize,
+		batchPause: opts.batchPause,
+		runIDs:     opts.runIDs,
+		workspaces: opts.workspaces,
+		excludeOwn: opts.excludeOwn,
+		estimate:   opts.estimate,
+		autoApply:  opts.autoApply,
+		actionable: opts.actionable,
+		touched:    map[string]bool{},
+		changed:    map[string]bool{},
+		outcomes:   map[string]int{},
+		pinned:     map[string]*tfe.ConfigurationVersion{},
+		skipErrors: map[string]bool{},
+		skipped:    map[string]int{},
+		pauser:     newPauser(),
+	}
+	if c.batchID == "" {
+		c.batchID = newBatchID()
+	}
+	c.annotation = newAnnotation(c.batchID)
+	for _, kind := range opts.skipErrors {
+		c.skipErrors[kind] = true
+	}
+	if c.permissions, err = openPermissionCache(opts.permCache, opts.permTTL); err != nil {
+		return &Client{}, err
+	}
+	if opts.exec != "" {
+		if c.exec, err = parseExecTemplate(opts.exec); err != nil {
+			return &Client{}, fmt.Errorf("invalid -exec template: %w", err)
+		}
+	}
+	if opts.ledger != "" {
+		if c.ledger, err = openLedger(opts.ledger, opts.action, opts.batchID); err != nil {
+			return &Client{}, err
+		}
+	}
+	if opts.rules != "" {
+		if c.rules, err = openRules(opts.rules); err != nil {
+			return &Client{}, err
+		}
+	}
+	if opts.checklist != "" {
+		if c.checklist, err = openChecklist(opts.checklist); err != nil {
+			return &Client{}, err
+		}
+	}
+	if opts.policy != "" {
+		check, err := newPlanPolicyCheck(opts.policy)
+		if err != nil {
+			return &Client{}, err
+		}
+		if c.checklist == nil {
+			c.checklist = &Checklist{}
+		}
+		c.checklist.checks = append(c.checklist.checks, check)
+	}
+	if opts.approval != "" {
+		if c.checklist == nil {
+			c.checklist = &Checklist{}
+		}
+		c.checklist.checks = append(c.checklist.checks, newApprovalCheck(opts.approval))
+	}
+
+	return c, nil
 }
 
 // Print out the Workspace(s)
@@ -100,70 +878,295 @@ func (c *Client) Echo(ctx context.Context, org, search string) error {
 	return nil
 }
 
+// The CurrentRun, or with -commit-sha or -config-version every Run in the statuses created from it
+func (c *Client) runsToSweep(ctx context.Context, ws *tfe.Workspace, statuses []tfe.RunStatus, origin RunOrigin) ([]*tfe.Run, error) {
+	if !origin.set() {
+		return []*tfe.Run{ws.CurrentRun}, nil
+	}
+
+	runs, err := c.getRunsFrom(ctx, ws.ID, statuses, origin)
+	if err != nil {
+		return nil, c.tolerate(ws.Name, err)
+	}
+	for _, run := range runs {
+		slog.Info("created from", "workspace", ws.Name, "runID", run.ID, "status", run.Status, "configVersion", run.ConfigurationVersion.ID, "commit", commitSHA(run.ConfigurationVersion))
+	}
+	return runs, nil
+}
+
+// Which Workspaces the run action starts Runs on, and what from
+type RunOptions struct {
+	// Only Workspaces whose current Run errored, with -errored-only
+	ErroredOnly bool
+	// Start a Run even if an identical one is waiting, with -force-duplicate
+	ForceDuplicate bool
+	// Start Runs on agent pools in waves no larger than their idle agents, with -agent-waves
+	AgentWaves bool
+	// Skip Workspaces whose latest ingress failed, with -check-ingress
+	CheckIngress bool
+	// Start from the latest ingressed Configuration Version, with -reuse-config-version
+	ReuseConfig bool
+	// Abort unless every agent pool has this many agents, with -require-agents
+	RequireAgents int
+	// Start from the Configuration Version of this commit, with -commit-sha, if set
+	Commit string
+}
+
 // Start a new Run if possible
-func (c *Client) Run(ctx context.Context, org, search string, assume, erroredOnly bool) error {
+func (c *Client) Run(ctx context.Context, org, search string, assume bool, opts RunOptions) error {
+	if opts.ReuseConfig && opts.Commit != "" {
+		return fmt.Errorf("-reuse-config-version and -commit-sha can't be used together")
+	}
+
 	workspaces, err := c.getWorkspaces(ctx, org, search)
 	if err != nil {
 		return err
 	}
 
 	var createList []*tfe.Workspace
+	brokenIngress := 0
 	for _, ws := range workspaces {
-		if !erroredOnly || (erroredOnly && ws.CurrentRun.Status == tfe.RunErrored) {
-			if ws.Permissions.CanQueueRun {
-				slog.Info("can start", "workspace", ws.Name)
-				createList = append(createList, ws)
-			} else {
-				slog.Warn("missing permission", "workspace", ws.Name)
+		if !opts.ErroredOnly || (ws.CurrentRun != nil && ws.CurrentRun.Status == tfe.RunErrored) {
+			if !ws.Permissions.CanQueueRun {
+				c.missingPermission("workspace", ws.Name)
+				continue
+			}
+
+			if opts.Commit != "" {
+				cv, err := c.getConfigVersionForCommit(ctx, ws.ID, opts.Commit)
+				if err != nil {
+					if err := c.tolerate(ws.Name, err); err != nil {
+						return err
+					}
+					continue
+				}
+				if cv == nil || cv.Status != tfe.ConfigurationUploaded {
+					slog.Warn("skipping, commit not ingressed", "workspace", ws.Name, "commit", opts.Commit)
+					continue
+				}
+				slog.Info("pinned", "workspace", ws.Name, "commit", commitSHA(cv), "configVersion", cv.ID)
+				c.pinned[ws.ID] = cv
+			} else if opts.ReuseConfig {
+				// Start from what's already been ingressed, so a degraded VCS provider isn't asked for it again
+				cv, err := c.getLatestConfigVersion(ctx, ws.ID)
+				if err != nil {
+					if err := c.tolerate(ws.Name, err); err != nil {
+						return err
+					}
+					continue
+				}
+				if cv == nil {
+					slog.Warn("skipping, no configuration version to reuse", "workspace", ws.Name)
+					continue
+				}
+				slog.Info("pinned", "workspace", ws.Name, "commit", commitSHA(cv), "configVersion", cv.ID)
+				c.pinned[ws.ID] = cv
 			}
+
+			if !opts.ForceDuplicate {
+				duplicate, err := c.getDuplicateRun(ctx, ws)
+				if err != nil {
+					if err := c.tolerate(ws.Name, err); err != nil {
+						return err
+					}
+					continue
+				}
+				if duplicate != nil {
+					slog.Info("skipping, duplicate run waiting", "workspace", ws.Name, "runID", duplicate.ID, "status", duplicate.Status)
+					continue
+				}
+			}
+
+			if opts.CheckIngress {
+				reason, err := c.ingressError(ctx, ws)
+				if err != nil {
+					if err := c.tolerate(ws.Name, err); err != nil {
+						return err
+					}
+					continue
+				}
+				if reason != "" {
+					slog.Warn("skipping, ingress failing", "workspace", ws.Name, "repo", ws.VCSRepo.Identifier, "error", reason)
+					brokenIngress++
+					continue
+				}
+			}
+
+			slog.Info("can start", "workspace", ws.Name)
+			createList = append(createList, ws)
 		}
 	}
+	if brokenIngress > 0 {
+		slog.Warn(fmt.Sprintf("Skipped %d Workspace(s) failing to ingress, see -action branch-check", brokenIngress))
+	}
+
+	if err := c.agentPreflight(ctx, createList, opts.RequireAgents); err != nil {
+		return err
+	}
+	if err := c.estimateBatch(ctx, createList, "run", 0); err != nil {
+		return err
+	}
 
 	if confirm(len(createList), assume) {
-		for _, ws := range createList {
-			if run, err := c.createRun(ctx, ws); err != nil {
-				return err
-			} else {
-				slog.Info("started", "runID", run.ID)
+		return c.inGroups(ctx, org, createList, func(group []*tfe.Workspace) ([]*tfe.Run, error) {
+			return c.startRuns(ctx, group, opts.AgentWaves)
+		})
+	}
+
+	return nil
+}
+
+// Runs on agent pools are started in waves sized to the pool, everything else at once; Workspaces skipped over
+// with -skip-errors have no Run
+func (c *Client) startRuns(ctx context.Context, workspaces []*tfe.Workspace, agentWaves bool) ([]*tfe.Run, error) {
+	var started []*tfe.Run
+
+	pools := map[string][]*tfe.Workspace{}
+	var poolIDs []string
+	for _, ws := range workspaces {
+		if agentWaves && ws.ExecutionMode == "agent" && ws.AgentPoolID != "" {
+			if _, ok := pools[ws.AgentPoolID]; !ok {
+				poolIDs = append(poolIDs, ws.AgentPoolID)
 			}
+			pools[ws.AgentPoolID] = append(pools[ws.AgentPoolID], ws)
+			continue
+		}
+
+		run, err := c.startRun(ctx, ws)
+		if err != nil {
+			return started, err
+		}
+		if run != nil {
+			started = append(started, run)
 		}
 	}
 
-	return nil
+	for _, poolID := range poolIDs {
+		runs, err := c.createRunsInWaves(ctx, poolID, pools[poolID])
+		started = append(started, runs...)
+		if err != nil {
+			return started, err
+		}
+	}
+
+	return started, nil
+}
+
+// Start a Run, which is nil if the Workspace was skipped over with -skip-errors
+func (c *Client) startRun(ctx context.Context, ws *tfe.Workspace) (*tfe.Run, error) {
+	run, err := c.createRun(ctx, ws)
+	if err != nil {
+		return nil, c.tolerate(ws.Name, err)
+	}
+
+	slog.Info("started", "runID", run.ID)
+	c.acted(ctx, "run", ws, run)
+	return run, nil
 }
 
 // Confirm the CurrentRun if possible
-func (c *Client) Confirm(ctx context.Context, org, search string, assume bool) error {
+func (c *Client) Confirm(ctx context.Context, org, search string, assume bool, requireAgents int, minRunAge time.Duration, allowStale bool, reportFile string) error {
 	workspaces, err := c.getWorkspaces(ctx, org, search)
 	if err != nil {
 		return err
 	}
 
-	var confirmList []string
+	var applying []*tfe.Workspace
 	for _, ws := range workspaces {
-		if c.canConfirm(ws.Name, ws.CurrentRun) {
-			confirmList = append(confirmList, ws.CurrentRun.ID)
+		ok, err := c.confirmable(ctx, ws, ws.CurrentRun, minRunAge, allowStale)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if ok {
+			applying = append(applying, ws)
 		}
 	}
 
-	if confirm(len(confirmList), assume) {
-		return c.confirmRuns(ctx, confirmList)
+	if c.checklist != nil {
+		checklistFailed := 0
+		for _, evaluated := range c.checklist.evaluated {
+			if !evaluated.Passed {
+				checklistFailed++
+			}
+		}
+		slog.Info(fmt.Sprintf("Checklist passed on %d Run(s), failed on %d", len(applying), checklistFailed))
+		if err := c.writeChecklistReport(reportFile); err != nil {
+			return err
+		}
+	}
+
+	if err := c.agentPreflight(ctx, applying, requireAgents); err != nil {
+		return err
+	}
+	if err := c.estimateBatch(ctx, applying, "confirm", 0); err != nil {
+		return err
+	}
+
+	if confirm(len(applying), assume) {
+		return c.inGroups(ctx, org, applying, func(group []*tfe.Workspace) ([]*tfe.Run, error) {
+			var runs []*tfe.Run
+			for _, ws := range group {
+				if err := c.confirmRun(ctx, target{ws, ws.CurrentRun}); err != nil {
+					return runs, err
+				}
+				runs = append(runs, ws.CurrentRun)
+			}
+			return runs, nil
+		})
 	}
 
 	return nil
 }
 
+// Whether the Run passes every check made before confirming it, logging why not
+func (c *Client) confirmable(ctx context.Context, ws *tfe.Workspace, run *tfe.Run, minRunAge time.Duration, allowStale bool) (bool, error) {
+	if age := time.Since(plannedAt(run)); age < minRunAge {
+		slog.Info("skipping, planned too recently", "workspace", ws.Name, "runID", run.ID, "age", age.Round(time.Second))
+		return false, nil
+	}
+	if !c.canConfirm(ws.Name, run) {
+		return false, nil
+	}
+	if !allowStale {
+		reason, err := c.stalePlan(ctx, ws, run)
+		if err != nil {
+			return false, err
+		}
+		if reason != "" {
+			slog.Warn("skipping, stale plan", "workspace", ws.Name, "runID", run.ID, "reason", reason)
+			return false, nil
+		}
+	}
+	passed, err := c.passesChecklist(ctx, ws, run)
+	if err != nil {
+		return false, err
+	}
+	if !passed {
+		slog.Warn("skipping, checklist failed", "workspace", ws.Name, "runID", run.ID)
+	}
+	return passed, nil
+}
+
 // Discard the CurrentRun if possible
-func (c *Client) Discard(ctx context.Context, org, search string, assume bool) error {
+func (c *Client) Discard(ctx context.Context, org, search string, assume bool, origin RunOrigin) error {
 	workspaces, err := c.getWorkspaces(ctx, org, search)
 	if err != nil {
 		return err
 	}
 
-	var discardList []string
+	var discardList []target
 	for _, ws := range workspaces {
-		if c.canDiscard(ws.Name, ws.CurrentRun) {
-			discardList = append(discardList, ws.CurrentRun.ID)
+		runs, err := c.runsToSweep(ctx, ws, PLANNED_STATUSES, origin)
+		if err != nil {
+			return err
+		}
+		for _, run := range runs {
+			if c.canDiscard(ws.Name, run) {
+				discardList = append(discardList, target{ws, run})
+			}
 		}
 	}
 
@@ -175,16 +1178,22 @@ func (c *Client) Discard(ctx context.Context, org, search string, assume bool) e
 }
 
 // Cancel the CurrentRun if possible
-func (c *Client) Cancel(ctx context.Context, org, search string, assume bool) error {
+func (c *Client) Cancel(ctx context.Context, org, search string, assume bool, origin RunOrigin) error {
 	workspaces, err := c.getWorkspaces(ctx, org, search)
 	if err != nil {
 		return err
 	}
 
-	var cancelList []string
+	var cancelList []target
 	for _, ws := range workspaces {
-		if c.canCancel(ws.Name, ws.CurrentRun) {
-			cancelList = append(cancelList, ws.CurrentRun.ID)
+		runs, err := c.runsToSweep(ctx, ws, CANCELABLE_STATUSES, origin)
+		if err != nil {
+			return err
+		}
+		for _, run := range runs {
+			if c.canCancel(ws.Name, run) {
+				cancelList = append(cancelList, target{ws, run})
+			}
 		}
 	}
 
@@ -196,20 +1205,44 @@ func (c *Client) Cancel(ctx context.Context, org, search string, assume bool) er
 }
 
 // Given one or more pending Run: confirm, cancel, or discard Runs until there are 1 or fewer Runs
-func (c *Client) Cleanup(ctx context.Context, org, search string, assume bool, stuckStatus tfe.RunStatus) error {
+func (c *Client) Cleanup(ctx context.Context, org, search string, assume bool, stuckStatus tfe.RunStatus, discardPolicyFailed, requeue bool) error {
 	workspaces, err := c.getWorkspaces(ctx, org, search)
 	if err != nil {
 		return err
 	}
 
+	if stuckStatus == tfe.RunCostEstimated && !c.supports(FeatureCostEstimation) {
+		slog.Warn("cost estimation is disabled so runs never reach cost_estimated, try -stuck-status planned")
+	}
+
 	var (
-		confirmList []string
-		cancelList  []string
-		discardList []string
-		skipList    []string
+		confirmList []target
+		cancelList  []target
+		discardList []target
+		skipList    []target
+		// Current Runs a hard-mandatory policy failed, with -discard-policy-failed
+		policyList []target
 	)
 
 	for _, ws := range workspaces {
+		if discardPolicyFailed {
+			failed, err := c.policyHardFailed(ctx, ws.CurrentRun)
+			if err != nil {
+				if err := c.tolerate(ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			if failed {
+				if requeue && !ws.Permissions.CanQueueRun {
+					c.missingPermission("workspace", ws.Name)
+				} else if c.canDiscard(ws.Name, ws.CurrentRun) {
+					policyList = append(policyList, target{ws, ws.CurrentRun})
+				}
+				continue
+			}
+		}
+
 		if ws.CurrentRun.Status == stuckStatus {
 			runs, err := c.getWaitingRuns(ctx, ws.ID, stuckStatus)
 			if err != nil {
@@ -222,7 +1255,7 @@ func (c *Client) Cleanup(ctx context.Context, org, search string, assume bool, s
 					case stuckStatus:
 						if ws.AutoApply {
 							if c.canConfirm(ws.Name, run) {
-								confirmList = append(confirmList, run.ID)
+								confirmList = append(confirmList, target{ws, run})
 							}
 						} else {
 							slog.Info("skipping, autoapply disabled", "workspace", ws.Name, "runID", run.ID)
@@ -230,17 +1263,17 @@ func (c *Client) Cleanup(ctx context.Context, org, search string, assume bool, s
 					case tfe.RunPending:
 						// This one should queue automatically after cleanup
 						slog.Info("will trigger automatically", "workspace", ws.Name, "runID", run.ID)
-						skipList = append(skipList, run.ID)
+						skipList = append(skipList, target{ws, run})
 					}
 				} else {
 					switch run.Status {
 					case stuckStatus:
 						if c.canDiscard(ws.Name, run) {
-							discardList = append(discardList, run.ID)
+							discardList = append(discardList, target{ws, run})
 						}
 					case tfe.RunPending:
 						if c.canCancel(ws.Name, run) {
-							cancelList = append(cancelList, run.ID)
+							cancelList = append(cancelList, target{ws, run})
 						}
 					}
 				}
@@ -248,7 +1281,7 @@ func (c *Client) Cleanup(ctx context.Context, org, search string, assume bool, s
 		}
 	}
 
-	changeCount := len(confirmList) + len(cancelList) + len(discardList) + len(skipList)
+	changeCount := len(confirmList) + len(cancelList) + len(discardList) + len(skipList) + len(policyList)
 	if confirm(changeCount, assume) {
 		// Cancel should happen before Discard
 		if err := c.cancelRuns(ctx, cancelList); err != nil {
@@ -260,11 +1293,224 @@ func (c *Client) Cleanup(ctx context.Context, org, search string, assume bool, s
 		if err := c.confirmRuns(ctx, confirmList); err != nil {
 			return err
 		}
+		for _, t := range policyList {
+			if err := c.discardPolicyFailed(ctx, t, requeue); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Cancel or discard every waiting Run created before the cutoff, whatever its position in the queue
+func (c *Client) Expire(ctx context.Context, org, search string, assume bool, olderThan time.Duration) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var (
+		cancelList  []target
+		discardList []target
+	)
+
+	cutoff := time.Now().Add(-olderThan)
+	for _, ws := range workspaces {
+		runs, err := c.getRunsByStatus(ctx, ws.ID, WAITING_STATUSES)
+		if err != nil {
+			return err
+		}
+
+		for _, run := range runs {
+			if !run.CreatedAt.Before(cutoff) {
+				continue
+			}
+
+			slog.Info("expired", "workspace", ws.Name, "runID", run.ID, "status", run.Status, "age", time.Since(run.CreatedAt).Round(time.Second))
+			// Runs which have planned are discarded, anything earlier in the lifecycle is canceled
+			if run.Actions.IsDiscardable {
+				if c.canDiscard(ws.Name, run) {
+					discardList = append(discardList, target{ws, run})
+				}
+			} else if c.canCancel(ws.Name, run) {
+				cancelList = append(cancelList, target{ws, run})
+			}
+		}
+	}
+
+	if confirm(len(cancelList)+len(discardList), assume) {
+		// Cancel should happen before Discard
+		if err := c.cancelRuns(ctx, cancelList); err != nil {
+			return err
+		}
+		return c.discardRuns(ctx, discardList)
 	}
 
 	return nil
 }
 
+// Discard planned Runs whose Configuration Version is no longer the latest ingressed one
+func (c *Client) Supersede(ctx context.Context, org, search string, assume bool) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var discardList []target
+	for _, ws := range workspaces {
+		runs, err := c.getRunsByStatus(ctx, ws.ID, PLANNED_STATUSES)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			continue
+		}
+
+		latest, err := c.getLatestConfigVersion(ctx, ws.ID)
+		if err != nil {
+			return err
+		}
+		if latest == nil {
+			slog.Warn("no configuration version", "workspace", ws.Name)
+			continue
+		}
+
+		for _, run := range runs {
+			if run.ConfigurationVersion == nil || run.ConfigurationVersion.ID == latest.ID {
+				continue
+			}
+
+			slog.Info("superseded", "workspace", ws.Name, "runID", run.ID, "configVersion", run.ConfigurationVersion.ID, "latest", latest.ID)
+			if c.canDiscard(ws.Name, run) {
+				discardList = append(discardList, target{ws, run})
+			}
+		}
+	}
+
+	if confirm(len(discardList), assume) {
+		return c.discardRuns(ctx, discardList)
+	}
+
+	return nil
+}
+
+// Discard the CurrentRun and queue a fresh one in its place, Workspace by Workspace
+func (c *Client) Replan(ctx context.Context, org, search string, assume bool) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var replanList []target
+	for _, ws := range workspaces {
+		if !ws.Permissions.CanQueueRun {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+		if c.canDiscard(ws.Name, ws.CurrentRun) {
+			replanList = append(replanList, target{ws, ws.CurrentRun})
+		}
+	}
+
+	if confirm(len(replanList), assume) {
+		for _, t := range replanList {
+			if err := c.replanRun(ctx, t); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Only queue the new Run once the old one is discarded, so a Workspace is never left with both or neither
+func (c *Client) replanRun(ctx context.Context, t target) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+
+	slog.Info("discarding", "workspace", t.ws.Name, "runID", t.run.ID)
+	if err := c.Runs.Discard(ctx, t.run.ID, tfe.RunDiscardOptions{
+		Comment: tfe.String(fmt.Sprintf("Replanned by %s", c.annotation)),
+	}); err != nil {
+		return c.tolerate(t.ws.Name, err)
+	}
+
+	run, err := c.createRun(ctx, t.ws)
+	if err != nil {
+		return fmt.Errorf("%s: discarded %s but unable to queue a new run: %w", t.ws.Name, t.run.ID, err)
+	}
+
+	slog.Info("replanned", "workspace", t.ws.Name, "discardedRunID", t.run.ID, "runID", run.ID)
+	c.acted(ctx, "replan", t.ws, run)
+	return nil
+}
+
+// Find the most recent non-speculative Configuration Version which finished uploading
+func (c *Client) getLatestConfigVersion(ctx context.Context, workspaceID string) (*tfe.ConfigurationVersion, error) {
+	n := 0
+	for {
+		opts := &tfe.ConfigurationVersionListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			Include: []tfe.ConfigVerIncludeOpt{
+				tfe.ConfigVerIngressAttributes,
+			},
+		}
+
+		cvList, err := c.ConfigurationVersions.List(ctx, workspaceID, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cv := range cvList.Items {
+			if !cv.Speculative && cv.Status == tfe.ConfigurationUploaded {
+				return cv, nil
+			}
+		}
+
+		if cvList.NextPage > n {
+			n = cvList.NextPage
+		} else {
+			return nil, nil
+		}
+	}
+}
+
+func (c *Client) getRunsByStatus(ctx context.Context, workspaceID string, statuses []tfe.RunStatus) ([]*tfe.Run, error) {
+	var runs []*tfe.Run
+
+	filter := make([]string, len(statuses))
+	for idx, status := range statuses {
+		filter[idx] = string(status)
+	}
+
+	n := 0
+	for {
+		opts := &tfe.RunListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			Status: strings.Join(filter, ","),
+		}
+
+		runList, err := c.Runs.List(ctx, workspaceID, opts)
+		if err != nil {
+			return runs, err
+		}
+
+		runs = append(runs, runList.Items...)
+
+		if runList.NextPage > n {
+			n = runList.NextPage
+		} else {
+			return runs, nil
+		}
+	}
+}
+
 func (c *Client) getWaitingRuns(ctx context.Context, workspaceID string, stuckStatus tfe.RunStatus) ([]*tfe.Run, error) {
 	var runs []*tfe.Run
 
@@ -296,15 +1542,104 @@ func (c *Client) getWaitingRuns(ctx context.Context, workspaceID string, stuckSt
 	}
 }
 
+// A waiting Run which would plan the same thing as a new one: the latest (or pinned) Configuration Version and not a
+// destroy
+func (c *Client) getDuplicateRun(ctx context.Context, ws *tfe.Workspace) (*tfe.Run, error) {
+	runs, err := c.getRunsByStatus(ctx, ws.ID, WAITING_STATUSES)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+
+	latest := c.pinned[ws.ID]
+	if latest == nil {
+		if latest, err = c.getLatestConfigVersion(ctx, ws.ID); err != nil || latest == nil {
+			return nil, err
+		}
+	}
+
+	for _, run := range runs {
+		if !run.IsDestroy && run.ConfigurationVersion != nil && run.ConfigurationVersion.ID == latest.ID {
+			return run, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Why applying the Run would apply outdated changes, or "" if it's the latest: its Configuration Version is no
+// longer the latest, or a newer Run is still going or has applied
+func (c *Client) stalePlan(ctx context.Context, ws *tfe.Workspace, run *tfe.Run) (string, error) {
+	latest, err := c.getLatestConfigVersion(ctx, ws.ID)
+	if err != nil {
+		return "", err
+	}
+	if latest != nil && run.ConfigurationVersion != nil && run.ConfigurationVersion.ID != latest.ID {
+		return fmt.Sprintf("configuration version %s is not the latest %s", run.ConfigurationVersion.ID, latest.ID), nil
+	}
+
+	// Runs are listed newest first, so only those since the Run are read, however many pages that takes
+	newerRuns, err := c.getRunsSince(ctx, ws.ID, run.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+	for _, newer := range newerRuns {
+		if newer.ID == run.ID || newer.PlanOnly || !newer.CreatedAt.After(run.CreatedAt) {
+			continue
+		}
+		// A newer Run which ended without applying leaves the plan as current as it was
+		if slices.Contains(DEAD_STATUSES, newer.Status) || slices.Contains(NOTHING_TO_APPLY_STATUSES, newer.Status) {
+			continue
+		}
+		return fmt.Sprintf("newer run %s is %s", newer.ID, newer.Status), nil
+	}
+
+	return "", nil
+}
+
 func (c *Client) createRun(ctx context.Context, workspace *tfe.Workspace) (*tfe.Run, error) {
+	if err := c.pauser.wait(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.throttle(ctx); err != nil {
+		return nil, err
+	}
+
 	opts := tfe.RunCreateOptions{
-		Workspace: workspace,
+		Workspace:            workspace,
+		ConfigurationVersion: c.pinned[workspace.ID],
+		Message:              tfe.String(fmt.Sprintf("Queued by %s", c.annotation)),
+	}
+	// The apply action confirms its own Runs, so only the run action applies them automatically
+	if c.autoApply && c.action == "run" {
+		opts.AutoApply = tfe.Bool(true)
 	}
 
 	return c.Runs.Create(ctx, opts)
 }
 
+// When the Run's plan finished, falling back to when the Run was created
+func plannedAt(run *tfe.Run) time.Time {
+	if run.StatusTimestamps != nil && !run.StatusTimestamps.PlannedAt.IsZero() {
+		return run.StatusTimestamps.PlannedAt
+	}
+	return run.CreatedAt
+}
+
+// Whether the config lets the operation be done from the Run's status
+func (c *Client) actionableStatus(operation, name, runID string, status tfe.RunStatus) bool {
+	statuses, ok := c.actionable[operation]
+	if !ok || slices.Contains(statuses, status) {
+		return true
+	}
+
+	slog.Warn("skipping, status not actionable", "workspace", name, "runID", runID, "status", status, "operation", operation)
+	return false
+}
+
 func (c *Client) canConfirm(name string, run *tfe.Run) bool {
+	if !c.actionableStatus("confirm", name, run.ID, run.Status) {
+		return false
+	}
 	if run.Permissions.CanApply {
 		if run.Actions.IsConfirmable {
 			slog.Info("can confirm", "workspace", name, "runID", run.ID)
@@ -315,25 +1650,44 @@ func (c *Client) canConfirm(name string, run *tfe.Run) bool {
 		}
 	}
 
-	slog.Warn("missing permission", "workspace", name, "runID", run.ID)
+	c.missingPermission("workspace", name, "runID", run.ID)
 	return false
 }
 
-func (c *Client) confirmRuns(ctx context.Context, runIDs []string) error {
-	for _, runID := range runIDs {
-		if err := c.confirmRun(ctx, runID); err != nil {
+func (c *Client) confirmRuns(ctx context.Context, targets []target) error {
+	for _, t := range targets {
+		if err := c.confirmRun(ctx, t); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (c *Client) confirmRun(ctx context.Context, runID string) error {
-	slog.Info("confirming", "runID", runID)
-	return c.Runs.Apply(ctx, runID, tfe.RunApplyOptions{})
+func (c *Client) confirmRun(ctx context.Context, t target) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+	if err := c.throttle(ctx); err != nil {
+		return err
+	}
+
+	slog.Info("confirming", "runID", t.run.ID)
+	if err := c.Runs.Apply(ctx, t.run.ID, tfe.RunApplyOptions{Comment: tfe.String(fmt.Sprintf("Confirmed by %s", c.annotation))}); err != nil {
+		return c.tolerate(t.ws.Name, err)
+	}
+
+	c.acted(ctx, "confirm", t.ws, t.run)
+	return nil
 }
 
 func (c *Client) canCancel(name string, run *tfe.Run) bool {
+	if c.excludeOwn && isOwnRun(run) {
+		slog.Info("skipping, run queued by go-tfe-bulk", "workspace", name, "runID", run.ID)
+		return false
+	}
+	if !c.actionableStatus("cancel", name, run.ID, run.Status) {
+		return false
+	}
 	if run.Permissions.CanCancel {
 		if run.Actions.IsCancelable {
 			slog.Info("can cancel", "workspace", name, "runID", run.ID)
@@ -344,25 +1698,42 @@ func (c *Client) canCancel(name string, run *tfe.Run) bool {
 		}
 	}
 
-	slog.Warn("missing permission", "workspace", name, "runID", run.ID)
+	c.missingPermission("workspace", name, "runID", run.ID)
 	return false
 }
 
-func (c *Client) cancelRuns(ctx context.Context, runIDs []string) error {
-	for _, runID := range runIDs {
-		if err := c.cancelRun(ctx, runID); err != nil {
+func (c *Client) cancelRuns(ctx context.Context, targets []target) error {
+	for _, t := range targets {
+		if err := c.cancelRun(ctx, t); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (c *Client) cancelRun(ctx context.Context, runID string) error {
-	slog.Info("canceling", "runID", runID)
-	return c.Runs.Cancel(ctx, runID, tfe.RunCancelOptions{})
+func (c *Client) cancelRun(ctx context.Context, t target) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+
+	slog.Info("canceling", "runID", t.run.ID)
+	if err := c.Runs.Cancel(ctx, t.run.ID, tfe.RunCancelOptions{}); err != nil {
+		return c.tolerate(t.ws.Name, err)
+	}
+
+	c.acted(ctx, "cancel", t.ws, t.run)
+	c.addReceipt(ctx, "cancel", t)
+	return nil
 }
 
 func (c *Client) canDiscard(name string, run *tfe.Run) bool {
+	if c.excludeOwn && isOwnRun(run) {
+		slog.Info("skipping, run queued by go-tfe-bulk", "workspace", name, "runID", run.ID)
+		return false
+	}
+	if !c.actionableStatus("discard", name, run.ID, run.Status) {
+		return false
+	}
 	if run.Permissions.CanDiscard {
 		if run.Actions.IsDiscardable {
 			slog.Info("can discard", "workspace", name, "runID", run.ID)
@@ -373,25 +1744,110 @@ func (c *Client) canDiscard(name string, run *tfe.Run) bool {
 		}
 	}
 
-	slog.Warn("missing permission", "workspace", name, "runID", run.ID)
+	c.missingPermission("workspace", name, "runID", run.ID)
 	return false
 }
 
-func (c *Client) discardRuns(ctx context.Context, runIDs []string) error {
-	for _, runID := range runIDs {
-		if err := c.discardRun(ctx, runID); err != nil {
+func (c *Client) discardRuns(ctx context.Context, targets []target) error {
+	for _, t := range targets {
+		if err := c.discardRun(ctx, t); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (c *Client) discardRun(ctx context.Context, runID string) error {
-	slog.Info("discarding", "runID", runID)
-	return c.Runs.Discard(ctx, runID, tfe.RunDiscardOptions{})
+func (c *Client) discardRun(ctx context.Context, t target) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+
+	slog.Info("discarding", "runID", t.run.ID)
+	if err := c.Runs.Discard(ctx, t.run.ID, tfe.RunDiscardOptions{}); err != nil {
+		return c.tolerate(t.ws.Name, err)
+	}
+
+	c.acted(ctx, "discard", t.ws, t.run)
+	c.addReceipt(ctx, "discard", t)
+	return nil
 }
 
 func (c *Client) getWorkspaces(ctx context.Context, org, search string) ([]*tfe.Workspace, error) {
+	listed, err := c.listWorkspaces(ctx, org, search)
+	if err != nil {
+		return nil, err
+	}
+
+	var workspaces []*tfe.Workspace
+	for _, ws := range listed {
+		slog.Debug("listed", "workspace", ws.Name, "search", search)
+		if c.ledger.processed(ws.ID) {
+			slog.Info("skipping, already processed in batch", "workspace", ws.Name)
+			continue
+		}
+		if rule := c.rules.denies(c.action, ws, time.Now()); rule != "" {
+			slog.Warn("skipping, denied by rule", "workspace", ws.Name, "rule", rule)
+			continue
+		}
+		if ws.CurrentRun == nil {
+			if c.fromBatch != "" || slices.Contains(RUN_ACTIONS, c.action) {
+				slog.Debug("skipping, no current run", "workspace", ws.Name)
+				continue
+			}
+			slog.Debug("selected", "workspace", ws.Name)
+			workspaces = append(workspaces, ws)
+			continue
+		}
+		if c.fromBatch != "" && runBatchID(ws.CurrentRun) != c.fromBatch {
+			slog.Debug("skipping, current run not queued by batch", "workspace", ws.Name, "fromBatch", c.fromBatch)
+			continue
+		}
+		slog.Debug("selected", "workspace", ws.Name, "runID", ws.CurrentRun.ID, "status", ws.CurrentRun.Status)
+		workspaces = append(workspaces, ws)
+	}
+
+	slog.Info(fmt.Sprintf("Found %d Workspace(s)", len(workspaces)))
+	if err := c.previewWorkspaces(ctx, org, workspaces); err != nil {
+		return nil, err
+	}
+	if err := c.sortWorkspaces(ctx, org, workspaces); err != nil {
+		return nil, err
+	}
+	return c.interleaveWorkspaces(ctx, org, workspaces)
+}
+
+// Every Workspace matching the search, listed once; those acted on since are read again so later actions of
+// a composite -action see their new current Run
+func (c *Client) listWorkspaces(ctx context.Context, org, search string) ([]*tfe.Workspace, error) {
+	if c.selection != nil {
+		for idx, ws := range c.selection {
+			if !c.touched[ws.ID] {
+				continue
+			}
+			var refreshed *tfe.Workspace
+			var err error
+			if c.runIDs != nil {
+				refreshed, err = c.getRunWorkspace(ctx, ws.CurrentRun.ID)
+			} else {
+				refreshed, err = c.Workspaces.ReadByIDWithOptions(ctx, ws.ID, &tfe.WorkspaceReadOptions{
+					Include: []tfe.WSIncludeOpt{
+						"current_run",
+					},
+				})
+			}
+			if err != nil {
+				return nil, err
+			}
+			c.selection[idx] = refreshed
+			delete(c.touched, ws.ID)
+		}
+		return c.selection, nil
+	}
+
+	if c.runIDs != nil {
+		return c.listRunWorkspaces(ctx)
+	}
+
 	var workspaces []*tfe.Workspace
 
 	n := 0
@@ -411,22 +1867,40 @@ func (c *Client) getWorkspaces(ctx context.Context, org, search string) ([]*tfe.
 			return workspaces, err
 		}
 
-		for _, ws := range wsList.Items {
-			if ws.CurrentRun != nil {
-				workspaces = append(workspaces, ws)
-			}
+		workspaces = append(workspaces, wsList.Items...)
+		// Large Organizations take a while to list, so show it's getting somewhere
+		if p := wsList.Pagination; p != nil && p.TotalPages > 1 {
+			slog.Info("listing workspaces", "page", p.CurrentPage, "pages", p.TotalPages, "listed", len(workspaces), "total", p.TotalCount)
 		}
 
 		if wsList.NextPage > n {
 			n = wsList.NextPage
 		} else {
-			slog.Info(fmt.Sprintf("Found %d Workspace(s)", len(workspaces)))
+			if c.workspaces != nil {
+				workspaces = c.restrictWorkspaces(workspaces)
+			}
+			c.selection = workspaces
 			return workspaces, nil
 		}
 	}
 }
 
+// Switch to the next action of a composite -action
+func (c *Client) setAction(action string) {
+	c.action = action
+	c.ledger.use(action)
+	batchQuorum.setAction(action)
+}
+
 func confirm(changeCount int, assume bool) bool {
+	explanation.flush(os.Stdout)
+	preview.show(os.Stdout, !assume)
+
+	// The quorum is checked whatever the count, to start collecting the next prompt's changes afresh
+	if !batchQuorum.met(changeCount) {
+		slog.Info("Action(s) aborted")
+		return false
+	}
 	if changeCount > 0 {
 		if assume || confirmPrompt() {
 			return true
@@ -440,16 +1914,18 @@ func confirm(changeCount int, assume bool) bool {
 }
 
 func confirmPrompt() bool {
-	fmt.Print("Do you confirm the above action(s)? [y|N] ")
+	estimate := ""
+	if batchEstimate != "" {
+		estimate = fmt.Sprintf(", estimated to take %s", batchEstimate)
+		batchEstimate = ""
+	}
+	fmt.Printf("Do you confirm the above action(s)%s? [y|N] ", estimate)
 
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	input, err := readLine()
 	if err != nil {
 		return false
 	}
 
-	input = strings.TrimSuffix(input, "\n")
-
 	if input == "y" || input == "yes" {
 		return true
 	}
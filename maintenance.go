@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// Statuses where a Run is actively doing work, which locking the Workspace does not stop
+var IN_FLIGHT_STATUSES = []tfe.RunStatus{
+	tfe.RunFetching,
+	tfe.RunFetchingCompleted,
+	tfe.RunPrePlanRunning,
+	tfe.RunPrePlanCompleted,
+	tfe.RunQueuing,
+	tfe.RunPlanQueued,
+	tfe.RunPlanning,
+	tfe.RunCostEstimating,
+	tfe.RunPolicyChecking,
+	tfe.RunPostPlanRunning,
+	tfe.RunConfirmed,
+	tfe.RunApplyQueued,
+	tfe.RunApplying,
+}
+
+const inFlightPollInterval = 15 * time.Second
+
+// The Workspaces locked by a maintenance window, so that ending it only unlocks those
+type MaintenanceState struct {
+	Organization string               `json:"organization"`
+	Reason       string               `json:"reason"`
+	StartedAt    time.Time            `json:"startedAt"`
+	Workspaces   []MaintenanceLockRef `json:"workspaces"`
+}
+
+type MaintenanceLockRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Lock the Workspace(s) and wait for (or cancel) in-flight Runs, recording what was locked in the state file
+func (c *Client) Maintenance(ctx context.Context, org, search string, assume bool, reason string, cancelInFlight bool, stateFile string) error {
+	if _, err := os.Stat(stateFile); err == nil {
+		return fmt.Errorf("maintenance already in progress, end it first or remove %s", stateFile)
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var (
+		lockList   []*tfe.Workspace
+		cancelList []target
+	)
+
+	for _, ws := range workspaces {
+		if ws.Locked {
+			slog.Info("skipping, already locked", "workspace", ws.Name)
+			continue
+		}
+		if !ws.Permissions.CanLock {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		slog.Info("can lock", "workspace", ws.Name)
+		lockList = append(lockList, ws)
+
+		if ws.CurrentRun != nil && slices.Contains(IN_FLIGHT_STATUSES, ws.CurrentRun.Status) {
+			if !cancelInFlight {
+				slog.Info("will wait for", "workspace", ws.Name, "runID", ws.CurrentRun.ID, "status", ws.CurrentRun.Status)
+			} else if c.canCancel(ws.Name, ws.CurrentRun) {
+				cancelList = append(cancelList, target{ws, ws.CurrentRun})
+			}
+		}
+	}
+
+	if !confirm(len(lockList), assume) {
+		return nil
+	}
+
+	state := &MaintenanceState{
+		Organization: org,
+		Reason:       reason,
+		StartedAt:    time.Now().UTC(),
+	}
+
+	for _, ws := range lockList {
+		if err := c.pauser.wait(ctx); err != nil {
+			return err
+		}
+		slog.Info("locking", "workspace", ws.Name)
+		if _, err := c.Workspaces.Lock(ctx, ws.ID, tfe.WorkspaceLockOptions{Reason: tfe.String(reason)}); err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		c.acted(ctx, "lock", ws, nil)
+
+		// Saved after every lock so a failure part way through can still be ended cleanly
+		state.Workspaces = append(state.Workspaces, MaintenanceLockRef{ID: ws.ID, Name: ws.Name})
+		if err := writeMaintenanceState(stateFile, state); err != nil {
+			return err
+		}
+	}
+
+	if err := c.cancelRuns(ctx, cancelList); err != nil {
+		return err
+	}
+
+	return c.waitForInFlight(ctx, lockList)
+}
+
+// Unlock the Workspaces recorded in the state file and remove it
+func (c *Client) EndMaintenance(ctx context.Context, assume bool, stateFile string) error {
+	b, err := os.ReadFile(stateFile)
+	if err != nil {
+		return err
+	}
+
+	state := &MaintenanceState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return fmt.Errorf("%s: %w", stateFile, err)
+	}
+
+	slog.Info("ending maintenance", "reason", state.Reason, "startedAt", state.StartedAt)
+	var unlocking, kept []MaintenanceLockRef
+	for _, ref := range state.Workspaces {
+		rule, err := c.deniedByRule(ctx, ref.ID)
+		if err != nil {
+			return err
+		}
+		if rule != "" {
+			slog.Warn("skipping, denied by rule", "workspace", ref.Name, "rule", rule)
+			kept = append(kept, ref)
+			continue
+		}
+		slog.Info("will unlock", "workspace", ref.Name)
+		unlocking = append(unlocking, ref)
+	}
+
+	if !confirm(len(unlocking), assume) {
+		return nil
+	}
+
+	for i, ref := range unlocking {
+		ws, err := c.unlockRef(ctx, ref)
+		if err != nil {
+			// The Workspaces left locked stay in the state file, so ending maintenance again picks up where this stopped
+			state.Workspaces = append(kept, unlocking[i:]...)
+			if err := writeMaintenanceState(stateFile, state); err != nil {
+				slog.Error("unable to save maintenance state", "stateFile", stateFile, "error", err)
+			}
+			return err
+		}
+		c.acted(ctx, "unlock", ws, nil)
+	}
+
+	// Workspaces a rule kept locked stay in the state file, to unlock once the rules allow it
+	if len(kept) > 0 {
+		slog.Info(fmt.Sprintf("Kept %d Workspace(s) locked", len(kept)), "stateFile", stateFile)
+		state.Workspaces = kept
+		return writeMaintenanceState(stateFile, state)
+	}
+	return os.Remove(stateFile)
+}
+
+func (c *Client) unlockRef(ctx context.Context, ref MaintenanceLockRef) (*tfe.Workspace, error) {
+	if err := c.pauser.wait(ctx); err != nil {
+		return nil, err
+	}
+	slog.Info("unlocking", "workspace", ref.Name)
+	return c.Workspaces.Unlock(ctx, ref.ID)
+}
+
+// Poll until none of the Workspaces have a Run doing work
+func (c *Client) waitForInFlight(ctx context.Context, workspaces []*tfe.Workspace) error {
+	pending := workspaces
+	for {
+		var next []*tfe.Workspace
+		for _, ws := range pending {
+			current, err := c.Workspaces.ReadByIDWithOptions(ctx, ws.ID, &tfe.WorkspaceReadOptions{
+				Include: []tfe.WSIncludeOpt{tfe.WSCurrentRun},
+			})
+			if err != nil {
+				return err
+			}
+
+			if current.CurrentRun != nil && slices.Contains(IN_FLIGHT_STATUSES, current.CurrentRun.Status) {
+				next = append(next, current)
+			}
+		}
+
+		if len(next) == 0 {
+			slog.Info("No Runs in flight")
+			return nil
+		}
+
+		slog.Info(fmt.Sprintf("Waiting for %d Run(s) in flight", len(next)))
+		pending = next
+
+		if err := sleep(ctx, inFlightPollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+func writeMaintenanceState(path string, state *MaintenanceState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+// The settings the Workspaces should have, in YAML or JSON
+type Manifest struct {
+	Workspaces []*ManifestWorkspace `json:"workspaces" yaml:"workspaces"`
+}
+
+// Settings left out of the manifest aren't checked
+type ManifestWorkspace struct {
+	Name             string    `json:"name" yaml:"name"`
+	ExecutionMode    *string   `json:"executionMode,omitempty" yaml:"executionMode"`
+	AgentPoolID      *string   `json:"agentPoolID,omitempty" yaml:"agentPoolID"`
+	AutoApply        *bool     `json:"autoApply,omitempty" yaml:"autoApply"`
+	AllowDestroyPlan *bool     `json:"allowDestroyPlan,omitempty" yaml:"allowDestroyPlan"`
+	TerraformVersion *string   `json:"terraformVersion,omitempty" yaml:"terraformVersion"`
+	WorkingDirectory *string   `json:"workingDirectory,omitempty" yaml:"workingDirectory"`
+	VCSRepo          *string   `json:"vcsRepo,omitempty" yaml:"vcsRepo"`
+	VCSBranch        *string   `json:"vcsBranch,omitempty" yaml:"vcsBranch"`
+	Tags             *[]string `json:"tags,omitempty" yaml:"tags"`
+}
+
+// Where a Workspace differs from the manifest
+type ManifestDrift struct {
+	Workspace string `json:"workspace"`
+	// Not in the Organization at all
+	Missing bool `json:"missing,omitempty"`
+	// Each as "setting want -> got"
+	Settings []string `json:"settings,omitempty"`
+}
+
+type ManifestDriftReport struct {
+	Organization string           `json:"organization"`
+	CheckedAt    time.Time        `json:"checkedAt"`
+	Workspaces   int              `json:"workspaces"`
+	Drift        []*ManifestDrift `json:"drift"`
+}
+
+func readManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	switch filepath.Ext(path) {
+	case ".json":
+		err = json.Unmarshal(b, manifest)
+	default:
+		err = yaml.Unmarshal(b, manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for _, mw := range manifest.Workspaces {
+		if mw.Name == "" {
+			return nil, fmt.Errorf("%s: every workspace needs a name", path)
+		}
+		if seen[mw.Name] {
+			return nil, fmt.Errorf("%s: workspace %s is listed more than once", path, mw.Name)
+		}
+		seen[mw.Name] = true
+	}
+	return manifest, nil
+}
+
+// Report the settings of the manifest's Workspaces which have drifted from it, without changing anything. Fails when
+// any have, so a scheduled check notices
+func (c *Client) ManifestDiff(ctx context.Context, org, manifestFile, reportFile string) error {
+	if manifestFile == "" {
+		return fmt.Errorf("-manifest-file is required for manifest-diff")
+	}
+	manifest, err := readManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	report := &ManifestDriftReport{
+		Organization: org,
+		CheckedAt:    time.Now().UTC(),
+		Workspaces:   len(manifest.Workspaces),
+		Drift:        []*ManifestDrift{},
+	}
+	for _, mw := range manifest.Workspaces {
+		ws, err := c.Workspaces.Read(ctx, org, mw.Name)
+		if errors.Is(err, tfe.ErrResourceNotFound) {
+			slog.Warn("missing from organization", "workspace", mw.Name)
+			report.Drift = append(report.Drift, &ManifestDrift{Workspace: mw.Name, Missing: true})
+			continue
+		}
+		if err != nil {
+			if err := c.tolerate(mw.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		settings, err := mw.drift(ws)
+		if err != nil {
+			return err
+		}
+		if len(settings) == 0 {
+			slog.Info("matches manifest", "workspace", ws.Name)
+			continue
+		}
+		slog.Warn("drifted from manifest", "workspace", ws.Name, "settings", settings)
+		report.Drift = append(report.Drift, &ManifestDrift{Workspace: ws.Name, Settings: settings})
+	}
+
+	if err := c.writeJSONReport(reportFile, report); err != nil {
+		return err
+	}
+	if len(report.Drift) > 0 {
+		return fmt.Errorf("%d of %d Workspace(s) drifted from %s", len(report.Drift), len(manifest.Workspaces), manifestFile)
+	}
+	slog.Info(fmt.Sprintf("All %d Workspace(s) match %s", len(manifest.Workspaces), manifestFile))
+	return nil
+}
+
+// Describe every setting the manifest sets which the Workspace doesn't have
+func (mw *ManifestWorkspace) drift(ws *tfe.Workspace) ([]string, error) {
+	tags := append([]string{}, ws.TagNames...)
+	sort.Strings(tags)
+	live := &ManifestWorkspace{
+		Name:             ws.Name,
+		ExecutionMode:    &ws.ExecutionMode,
+		AgentPoolID:      &ws.AgentPoolID,
+		AutoApply:        &ws.AutoApply,
+		AllowDestroyPlan: &ws.AllowDestroyPlan,
+		TerraformVersion: &ws.TerraformVersion,
+		WorkingDirectory: &ws.WorkingDirectory,
+		VCSRepo:          tfe.String(""),
+		VCSBranch:        tfe.String(""),
+		Tags:             &tags,
+	}
+	if ws.VCSRepo != nil {
+		live.VCSRepo, live.VCSBranch = &ws.VCSRepo.Identifier, &ws.VCSRepo.Branch
+	}
+
+	want := *mw
+	if want.Tags != nil {
+		sorted := append([]string{}, *want.Tags...)
+		sort.Strings(sorted)
+		want.Tags = &sorted
+	}
+
+	wanted, err := toFields(&want)
+	if err != nil {
+		return nil, err
+	}
+	got, err := toFields(live)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := maps.Keys(wanted)
+	sort.Strings(fields)
+
+	var settings []string
+	for _, field := range fields {
+		if field != "name" && string(wanted[field]) != string(got[field]) {
+			settings = append(settings, fmt.Sprintf("%s %s -> %s", field, wanted[field], orNull(got[field])))
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+func TestManifestWorkspaceDrift(t *testing.T) {
+	live := &tfe.Workspace{
+		Name:             "network-prod",
+		ExecutionMode:    "agent",
+		AgentPoolID:      "apool-123",
+		TerraformVersion: "1.6.6",
+		TagNames:         []string{"prod", "network"},
+		VCSRepo:          &tfe.VCSRepo{Identifier: "myOrg/network", Branch: "main"},
+	}
+
+	tests := []struct {
+		name     string
+		manifest ManifestWorkspace
+		want     []string
+	}{
+		{
+			name:     "nothing set",
+			manifest: ManifestWorkspace{Name: "network-prod"},
+		},
+		{
+			name: "matching",
+			manifest: ManifestWorkspace{
+				Name:          "network-prod",
+				ExecutionMode: tfe.String("agent"),
+				AutoApply:     tfe.Bool(false),
+				VCSBranch:     tfe.String("main"),
+				Tags:          &[]string{"network", "prod"},
+			},
+		},
+		{
+			name: "drifted",
+			manifest: ManifestWorkspace{
+				Name:             "network-prod",
+				AutoApply:        tfe.Bool(true),
+				TerraformVersion: tfe.String("1.7.0"),
+				Tags:             &[]string{"prod"},
+			},
+			want: []string{
+				`autoApply true -> false`,
+				`tags ["prod"] -> ["network","prod"]`,
+				`terraformVersion "1.7.0" -> "1.6.6"`,
+			},
+		},
+		{
+			name:     "no tags wanted",
+			manifest: ManifestWorkspace{Name: "network-prod", Tags: &[]string{}},
+			want:     []string{`tags [] -> ["network","prod"]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.manifest.drift(live)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("drift() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManifestWorkspaceDriftWithoutVCS(t *testing.T) {
+	got, err := (&ManifestWorkspace{Name: "cli", VCSRepo: tfe.String("myOrg/cli")}).drift(&tfe.Workspace{Name: "cli"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`vcsRepo "myOrg/cli" -> ""`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("drift() = %q, want %q", got, want)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspaces.yaml")
+	if err := os.WriteFile(path, []byte("workspaces:\n  - name: dev\n    autoApply: true\n    tags: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := readManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ManifestWorkspace{Name: "dev", AutoApply: tfe.Bool(true), Tags: &[]string{}}
+	if len(manifest.Workspaces) != 1 || !reflect.DeepEqual(manifest.Workspaces[0], want) {
+		t.Errorf("readManifest() = %+v, want %+v", manifest.Workspaces, want)
+	}
+}
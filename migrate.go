@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Move the Workspace(s) in local execution mode, e.g. from CLI-driven workflows, to remote execution. Those without
+// a state version are skipped, since a remote plan would propose creating everything again. With verifyPlan a
+// plan-only Run is then queued on each, to check remote execution works and finds no changes
+func (c *Client) MigrateRemote(ctx context.Context, org, search string, assume, verifyPlan bool) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var migrateList []*tfe.Workspace
+	for _, ws := range workspaces {
+		if ws.ExecutionMode != "local" {
+			continue
+		}
+		if !ws.Permissions.CanUpdate || (verifyPlan && !ws.Permissions.CanQueueRun) {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		sv, err := c.StateVersions.ReadCurrent(ctx, ws.ID)
+		if errors.Is(err, tfe.ErrResourceNotFound) {
+			slog.Warn("skipping, no state version", "workspace", ws.Name)
+			continue
+		}
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		slog.Info("can migrate", "workspace", ws.Name, "stateVersionID", sv.ID, "serial", sv.Serial)
+		migrateList = append(migrateList, ws)
+	}
+
+	slog.Info(fmt.Sprintf("Found %d of %d Workspace(s) in local execution mode with state", len(migrateList), len(workspaces)))
+
+	if confirm(len(migrateList), assume) {
+		for _, ws := range migrateList {
+			if err := c.pauser.wait(ctx); err != nil {
+				return err
+			}
+
+			slog.Info("migrating to remote execution", "workspace", ws.Name)
+			if err := c.updateRawAttributes(ctx, ws, map[string]any{"execution-mode": "remote"}); err != nil {
+				if err := c.tolerate(ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			ws.ExecutionMode = "remote"
+
+			var run *tfe.Run
+			if verifyPlan {
+				if run, err = c.createVerificationRun(ctx, ws); err != nil {
+					return fmt.Errorf("%s: migrated but unable to queue a verification plan: %w", ws.Name, err)
+				}
+				slog.Info("verification plan queued", "workspace", ws.Name, "runID", run.ID)
+			}
+			c.acted(ctx, "migrate-remote", ws, run)
+		}
+	}
+
+	return nil
+}
+
+// A plan-only Run, which can never be applied
+func (c *Client) createVerificationRun(ctx context.Context, ws *tfe.Workspace) (*tfe.Run, error) {
+	if err := c.throttle(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.Runs.Create(ctx, tfe.RunCreateOptions{
+		Workspace: ws,
+		PlanOnly:  tfe.Bool(true),
+		Message:   tfe.String(fmt.Sprintf("Queued by %s", c.annotation)),
+	})
+}
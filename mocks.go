@@ -0,0 +1,141 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+const planExportPollInterval = 2 * time.Second
+
+// Download the Sentinel mock data of each Workspace's current Run into DIR/WORKSPACE/RUN-ID, for testing
+// policies against real plans
+func (c *Client) SentinelMocks(ctx context.Context, org, search, dir string) error {
+	if !c.supports(FeaturePolicyChecks) {
+		return fmt.Errorf("sentinel mocks are not available to this organization")
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	downloaded := 0
+	for _, ws := range workspaces {
+		run, err := c.Runs.ReadWithOptions(ctx, ws.CurrentRun.ID, &tfe.RunReadOptions{
+			Include: []tfe.RunIncludeOpt{tfe.RunPlan},
+		})
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if run.Plan == nil || run.Plan.Status != tfe.PlanFinished {
+			slog.Info("skipping, no finished plan", "workspace", ws.Name, "runID", run.ID, "status", run.Status)
+			continue
+		}
+
+		path := filepath.Join(dir, ws.Name, run.ID)
+		slog.Info("downloading mocks", "workspace", ws.Name, "runID", run.ID, "path", path)
+		if err := c.downloadMocks(ctx, run.Plan, path); err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		downloaded++
+	}
+
+	slog.Info(fmt.Sprintf("Downloaded mocks for %d of %d Workspace(s) to %s", downloaded, len(workspaces), dir))
+	return nil
+}
+
+// Export the plan's mock bundle, wait for it and extract it into the directory
+func (c *Client) downloadMocks(ctx context.Context, plan *tfe.Plan, path string) error {
+	dataType := tfe.PlanExportSentinelMockBundleV0
+	export, err := c.PlanExports.Create(ctx, tfe.PlanExportCreateOptions{
+		Plan:     plan,
+		DataType: &dataType,
+	})
+	if err != nil {
+		return err
+	}
+	// Exports expire on their own, but there's no reason to leave them until then
+	id := export.ID
+	defer func() {
+		if err := c.PlanExports.Delete(ctx, id); err != nil {
+			slog.Warn("unable to delete plan export", "planExportID", id, "error", err)
+		}
+	}()
+
+	for export.Status != tfe.PlanExportFinished {
+		switch export.Status {
+		case tfe.PlanExportCanceled, tfe.PlanExportErrored, tfe.PlanExportExpired:
+			return fmt.Errorf("plan export %s %s", id, export.Status)
+		}
+		if err := sleep(ctx, planExportPollInterval); err != nil {
+			return err
+		}
+		if export, err = c.PlanExports.Read(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	bundle, err := c.PlanExports.Download(ctx, id)
+	if err != nil {
+		return err
+	}
+	return extractTarGz(bundle, path)
+}
+
+// Extract the regular files in a .tar.gz into the directory, refusing any which would land outside it
+func extractTarGz(b []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		path := filepath.Join(dir, hdr.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("mock bundle entry %q is outside %s", hdr.Name, dir)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// The notification configurations mute disabled, so that unmute only re-enables those
+type MuteState struct {
+	Organization  string                 `json:"organization"`
+	StartedAt     time.Time              `json:"startedAt"`
+	Notifications []MutedNotificationRef `json:"notifications"`
+}
+
+type MutedNotificationRef struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	WorkspaceID string `json:"workspaceID"`
+	Workspace   string `json:"workspace"`
+}
+
+// A notification configuration to disable, and its Workspace
+type mutedNotification struct {
+	ws *tfe.Workspace
+	nc *tfe.NotificationConfiguration
+}
+
+// Disable every enabled notification configuration on the Workspace(s), recording what was disabled in the state
+// file, so bulk churn doesn't flood the channels they notify
+func (c *Client) Mute(ctx context.Context, org, search string, assume bool, stateFile string) error {
+	if _, err := os.Stat(stateFile); err == nil {
+		return fmt.Errorf("notifications already muted, unmute them first or remove %s", stateFile)
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var muteList []mutedNotification
+	for _, ws := range workspaces {
+		if !ws.Permissions.CanUpdate {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		ncs, err := c.getNotificationConfigurations(ctx, ws.ID)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, nc := range ncs {
+			if !nc.Enabled {
+				slog.Info("skipping, already disabled", "workspace", ws.Name, "notification", nc.Name)
+				continue
+			}
+			slog.Info("can mute", "workspace", ws.Name, "notification", nc.Name, "destination", nc.DestinationType)
+			muteList = append(muteList, mutedNotification{ws, nc})
+		}
+	}
+
+	if !confirm(len(muteList), assume) {
+		return nil
+	}
+
+	state := &MuteState{
+		Organization: org,
+		StartedAt:    time.Now().UTC(),
+	}
+
+	for _, muted := range muteList {
+		if err := c.pauser.wait(ctx); err != nil {
+			return err
+		}
+		slog.Info("muting", "workspace", muted.ws.Name, "notification", muted.nc.Name)
+		if _, err := c.NotificationConfigurations.Update(ctx, muted.nc.ID, tfe.NotificationConfigurationUpdateOptions{
+			Enabled: tfe.Bool(false),
+		}); err != nil {
+			if err := c.tolerate(muted.ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		c.acted(ctx, "mute", muted.ws, nil)
+
+		// Saved after every change so a failure part way through can still be unmuted cleanly
+		state.Notifications = append(state.Notifications, MutedNotificationRef{
+			ID:          muted.nc.ID,
+			Name:        muted.nc.Name,
+			WorkspaceID: muted.ws.ID,
+			Workspace:   muted.ws.Name,
+		})
+		if err := writeMuteState(stateFile, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Re-enable the notification configurations recorded in the state file and remove it
+func (c *Client) Unmute(ctx context.Context, assume bool, stateFile string) error {
+	b, err := os.ReadFile(stateFile)
+	if err != nil {
+		return err
+	}
+
+	state := &MuteState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return fmt.Errorf("%s: %w", stateFile, err)
+	}
+
+	slog.Info("ending mute", "startedAt", state.StartedAt)
+	var unmuting, kept []MutedNotificationRef
+	denied := map[string]string{}
+	for _, ref := range state.Notifications {
+		rule, checked := denied[ref.WorkspaceID]
+		if !checked {
+			var err error
+			if rule, err = c.deniedByRule(ctx, ref.WorkspaceID); err != nil {
+				return err
+			}
+			denied[ref.WorkspaceID] = rule
+		}
+		if rule != "" {
+			slog.Warn("skipping, denied by rule", "workspace", ref.Workspace, "notification", ref.Name, "rule", rule)
+			kept = append(kept, ref)
+			continue
+		}
+		slog.Info("will unmute", "workspace", ref.Workspace, "notification", ref.Name)
+		unmuting = append(unmuting, ref)
+	}
+
+	if !confirm(len(unmuting), assume) {
+		return nil
+	}
+
+	for i, ref := range unmuting {
+		if err := c.unmuteRef(ctx, ref); err != nil {
+			// The notifications left muted stay in the state file, so unmuting again picks up where this stopped
+			state.Notifications = append(kept, unmuting[i:]...)
+			if err := writeMuteState(stateFile, state); err != nil {
+				slog.Error("unable to save mute state", "muteFile", stateFile, "error", err)
+			}
+			return err
+		}
+		c.acted(ctx, "unmute", &tfe.Workspace{ID: ref.WorkspaceID, Name: ref.Workspace}, nil)
+	}
+
+	// Notifications a rule kept muted stay in the state file, to unmute once the rules allow it
+	if len(kept) > 0 {
+		slog.Info(fmt.Sprintf("Kept %d notification(s) muted", len(kept)), "muteFile", stateFile)
+		state.Notifications = kept
+		return writeMuteState(stateFile, state)
+	}
+	return os.Remove(stateFile)
+}
+
+func (c *Client) unmuteRef(ctx context.Context, ref MutedNotificationRef) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+	slog.Info("unmuting", "workspace", ref.Workspace, "notification", ref.Name)
+	_, err := c.NotificationConfigurations.Update(ctx, ref.ID, tfe.NotificationConfigurationUpdateOptions{
+		Enabled: tfe.Bool(true),
+	})
+	return err
+}
+
+func (c *Client) getNotificationConfigurations(ctx context.Context, workspaceID string) ([]*tfe.NotificationConfiguration, error) {
+	var ncs []*tfe.NotificationConfiguration
+
+	n := 0
+	for {
+		opts := &tfe.NotificationConfigurationListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+		}
+
+		ncList, err := c.NotificationConfigurations.List(ctx, workspaceID, opts)
+		if err != nil {
+			return ncs, err
+		}
+
+		ncs = append(ncs, ncList.Items...)
+
+		if ncList.NextPage > n {
+			n = ncList.NextPage
+		} else {
+			return ncs, nil
+		}
+	}
+}
+
+func writeMuteState(path string, state *MuteState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
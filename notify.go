@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How a batch went, as notifiers are told once it's finished, failed or ran out of time
+type BatchResult struct {
+	BatchID      string         `json:"batchID"`
+	Organization string         `json:"organization"`
+	Search       string         `json:"search,omitempty"`
+	Actions      []string       `json:"actions"`
+	StartedAt    time.Time      `json:"startedAt"`
+	FinishedAt   time.Time      `json:"finishedAt"`
+	Outcomes     map[string]int `json:"outcomes"`
+	// Empty if the batch finished
+	Error string `json:"error,omitempty"`
+}
+
+func (r *BatchResult) String() string {
+	var b strings.Builder
+
+	status := "finished"
+	if r.Error != "" {
+		status = "failed: " + r.Error
+	}
+	fmt.Fprintf(&b, "Batch %s of %s on %s %s", r.BatchID, strings.Join(r.Actions, ","), r.Organization, status)
+	if r.Search != "" {
+		fmt.Fprintf(&b, " (search: %s)", r.Search)
+	}
+	fmt.Fprintf(&b, "\n%s to %s\n", r.StartedAt.Format(time.RFC3339), r.FinishedAt.Format(time.RFC3339))
+
+	ops := make([]string, 0, len(r.Outcomes))
+	for op := range r.Outcomes {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		fmt.Fprintf(&b, "\n%-16s %d", op+":", r.Outcomes[op])
+	}
+	if len(ops) == 0 {
+		fmt.Fprint(&b, "\nNothing done")
+	}
+	return b.String()
+}
+
+// Tells a channel how a batch went
+type Notifier interface {
+	Notify(ctx context.Context, result *BatchResult) error
+}
+
+// Makes a Notifier from the target given to -notify, e.g. the URL after "slack="
+type NotifierFactory func(target string) (Notifier, error)
+
+var (
+	notifiersMu sync.Mutex
+	notifiers   = map[string]NotifierFactory{
+		"slack":   func(target string) (Notifier, error) { return &slackNotifier{url: target}, nil },
+		"webhook": func(target string) (Notifier, error) { return &webhookNotifier{url: target}, nil },
+		"email":   newEmailNotifier,
+	}
+)
+
+// Add a kind of -notify target, e.g. from an init function in a file of its own, so another channel can be plugged
+// in without changing the rest of the tool
+func RegisterNotifier(kind string, factory NotifierFactory) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers[kind] = factory
+}
+
+func notifierKinds() []string {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+
+	kinds := make([]string, 0, len(notifiers))
+	for kind := range notifiers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Notifiers from "kind=target,kind=target"
+func parseNotifiers(s string) ([]Notifier, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var parsed []Notifier
+	for _, pair := range strings.Split(s, ",") {
+		kind, target, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		notifiersMu.Lock()
+		factory := notifiers[kind]
+		notifiersMu.Unlock()
+		if !ok || factory == nil || target == "" {
+			return nil, fmt.Errorf("-notify: expected kind=target with kinds %s, got %q", strings.Join(notifierKinds(), ", "), pair)
+		}
+
+		n, err := factory(target)
+		if err != nil {
+			return nil, fmt.Errorf("-notify %s: %w", kind, err)
+		}
+		parsed = append(parsed, n)
+	}
+	return parsed, nil
+}
+
+// Tell every notifier how the batch went; failures are logged rather than changing how the batch exits
+func notifyAll(notifiers []Notifier, result *BatchResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, result); err != nil {
+			slog.Warn("unable to notify", "notifier", fmt.Sprintf("%T", n), "error", err)
+		}
+	}
+}
+
+// Posts the result as text to a Slack incoming webhook
+type slackNotifier struct {
+	url string
+}
+
+func (n *slackNotifier) Notify(ctx context.Context, result *BatchResult) error {
+	body, err := json.Marshal(map[string]string{"text": "```\n" + result.String() + "\n```"})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack returned %s", resp.Status)
+	}
+	return nil
+}
+
+// POSTs the result as JSON; the digest's -webhook-url posts the digest through it too
+type webhookNotifier struct {
+	url string
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, result *BatchResult) error {
+	return n.post(ctx, result)
+}
+
+// POST the payload as JSON, with a "text" field so Slack incoming webhooks render it
+func (n *webhookNotifier) post(ctx context.Context, payload fmt.Stringer) error {
+	body, err := json.Marshal(struct {
+		Text    string `json:"text"`
+		Payload any    `json:"payload"`
+	}{payload.String(), payload})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	slog.Info("webhook sent", "status", resp.StatusCode)
+
+	return nil
+}
+
+// Mails the result through the SMTP server in SMTP_ADDR (host:port) from SMTP_FROM, authenticating with
+// SMTP_USERNAME and SMTP_PASSWORD if they're set
+type emailNotifier struct {
+	addr string
+	from string
+	to   []string
+	auth smtp.Auth
+}
+
+// Recipients are separated by semicolons, since commas separate -notify targets
+func newEmailNotifier(target string) (Notifier, error) {
+	n := &emailNotifier{addr: os.Getenv("SMTP_ADDR"), from: os.Getenv("SMTP_FROM"), to: strings.Split(target, ";")}
+	if n.addr == "" || n.from == "" {
+		return nil, errors.New("SMTP_ADDR and SMTP_FROM are required")
+	}
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		host, _, _ := strings.Cut(n.addr, ":")
+		n.auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return n, nil
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, result *BatchResult) error {
+	status := "finished"
+	if result.Error != "" {
+		status = "failed"
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: go-tfe-bulk batch %s %s\r\n", result.BatchID, status)
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n", strings.ReplaceAll(result.String(), "\n", "\r\n"))
+
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, []byte(msg.String()))
+}
+
+func (c *Client) batchResult(org, search string, steps []string, start time.Time, err error) *BatchResult {
+	result := &BatchResult{
+		BatchID:      c.batchID,
+		Organization: org,
+		Search:       search,
+		Actions:      steps,
+		StartedAt:    start.UTC(),
+		FinishedAt:   time.Now().UTC(),
+		Outcomes:     c.outcomes,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+var SORT_KEYS = []string{"name", "last-run", "resource-count", "project"}
+
+// Put the Workspace(s) in the order they're processed, by default as listed
+func (c *Client) sortWorkspaces(ctx context.Context, org string, workspaces []*tfe.Workspace) error {
+	if c.sortBy == "" {
+		return nil
+	}
+
+	var less func(a, b *tfe.Workspace) bool
+	switch c.sortBy {
+	case "name":
+		less = func(a, b *tfe.Workspace) bool { return a.Name < b.Name }
+	case "last-run":
+		// Oldest first, so the most stale go first
+		less = func(a, b *tfe.Workspace) bool { return lastRunAt(a).Before(lastRunAt(b)) }
+	case "resource-count":
+		less = func(a, b *tfe.Workspace) bool { return a.ResourceCount < b.ResourceCount }
+	case "project":
+		projects, err := c.getProjectNames(ctx, org)
+		if err != nil {
+			return err
+		}
+		less = func(a, b *tfe.Workspace) bool {
+			if projects[a.ID] != projects[b.ID] {
+				return projects[a.ID] < projects[b.ID]
+			}
+			return a.Name < b.Name
+		}
+	default:
+		return fmt.Errorf("unsupported sort %q, expected one of %s", c.sortBy, strings.Join(SORT_KEYS, "|"))
+	}
+
+	sort.SliceStable(workspaces, func(i, j int) bool {
+		if c.reverse {
+			return less(workspaces[j], workspaces[i])
+		}
+		return less(workspaces[i], workspaces[j])
+	})
+
+	return nil
+}
+
+// When the current Run was created, or the zero time for Workspaces which have never had a Run
+func lastRunAt(ws *tfe.Workspace) time.Time {
+	if ws.CurrentRun == nil {
+		return time.Time{}
+	}
+	return ws.CurrentRun.CreatedAt
+}
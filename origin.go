@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// Where Runs came from, from -commit-sha and -config-version; either may be empty
+type RunOrigin struct {
+	// Full or abbreviated
+	CommitSHA       string
+	ConfigVersionID string
+}
+
+func (o RunOrigin) set() bool {
+	return o.CommitSHA != "" || o.ConfigVersionID != ""
+}
+
+// Whether the Run was created from the Configuration Version or commit; its Configuration Version must be included
+func (o RunOrigin) matches(run *tfe.Run) bool {
+	cv := run.ConfigurationVersion
+	if cv == nil {
+		return false
+	}
+	if o.ConfigVersionID != "" && cv.ID != o.ConfigVersionID {
+		return false
+	}
+	if o.CommitSHA != "" && (cv.IngressAttributes == nil || !strings.HasPrefix(cv.IngressAttributes.CommitSHA, o.CommitSHA)) {
+		return false
+	}
+	return true
+}
+
+// Statuses where the Run can still be canceled, for sweeping more than the CurrentRun
+var CANCELABLE_STATUSES = append([]tfe.RunStatus{tfe.RunPending}, IN_FLIGHT_STATUSES...)
+
+// The Workspace's Runs in the statuses created from the origin, newest first
+func (c *Client) getRunsFrom(ctx context.Context, workspaceID string, statuses []tfe.RunStatus, origin RunOrigin) ([]*tfe.Run, error) {
+	var runs []*tfe.Run
+
+	filter := make([]string, len(statuses))
+	for idx, status := range statuses {
+		filter[idx] = string(status)
+	}
+
+	// No Run from a Configuration Version is older than its ingress, so paging stops at the first which is
+	var since time.Time
+
+	n := 0
+	for {
+		opts := &tfe.RunListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			Status:  strings.Join(filter, ","),
+			Include: []tfe.RunIncludeOpt{tfe.RunConfigVer, tfe.RunConfigVerIngress},
+		}
+
+		runList, err := c.Runs.List(ctx, workspaceID, opts)
+		if err != nil {
+			return runs, err
+		}
+
+		for _, run := range runList.Items {
+			if !since.IsZero() && run.CreatedAt.Before(since) {
+				return runs, nil
+			}
+			if slices.Contains(statuses, run.Status) && origin.matches(run) {
+				runs = append(runs, run)
+				// The same commit may have been ingressed before, so only a Configuration Version bounds the search
+				if origin.ConfigVersionID != "" && since.IsZero() {
+					since = ingressedAt(run.ConfigurationVersion)
+				}
+			}
+		}
+
+		if runList.NextPage > n {
+			n = runList.NextPage
+		} else {
+			return runs, nil
+		}
+	}
+}
+
+// The Workspace's Configuration Version ingressed from the commit, or nil if it never was
+func (c *Client) getConfigVersionForCommit(ctx context.Context, workspaceID, sha string) (*tfe.ConfigurationVersion, error) {
+	origin := RunOrigin{CommitSHA: sha}
+
+	n := 0
+	for {
+		opts := &tfe.ConfigurationVersionListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			Include: []tfe.ConfigVerIncludeOpt{
+				tfe.ConfigVerIngressAttributes,
+			},
+		}
+
+		cvList, err := c.ConfigurationVersions.List(ctx, workspaceID, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cv := range cvList.Items {
+			if !cv.Speculative && origin.matches(&tfe.Run{ConfigurationVersion: cv}) {
+				return cv, nil
+			}
+		}
+
+		if cvList.NextPage > n {
+			n = cvList.NextPage
+		} else {
+			return nil, nil
+		}
+	}
+}
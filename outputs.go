@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// File formats outputs can be exported in, and the extension of each
+var OUTPUT_FORMATS = map[string]string{
+	"json":   ".json",
+	"dotenv": ".env",
+	"tfvars": ".tfvars",
+}
+
+var dotenvUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Write each of the Workspace(s)' current state outputs to DIR/WORKSPACE.EXT in the format, so downstream jobs can
+// consume them directly. Sensitive outputs are read one by one, and left out if the token can't read them
+func (c *Client) ExportOutputs(ctx context.Context, org, search, format, dir string) error {
+	ext, ok := OUTPUT_FORMATS[format]
+	if !ok {
+		return fmt.Errorf("unsupported -format %q for export-outputs, expected json, dotenv or tfvars", format)
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	exported := 0
+	for _, ws := range workspaces {
+		outputs, err := c.getOutputs(ctx, ws)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rendered, err := renderOutputs(outputs, format)
+		if err != nil {
+			return fmt.Errorf("workspace %s: %w", ws.Name, err)
+		}
+
+		path := filepath.Join(dir, ws.Name+ext)
+		slog.Info("exporting outputs", "workspace", ws.Name, "outputs", len(outputs), "path", path)
+		if err := os.WriteFile(path, rendered, 0o600); err != nil {
+			return err
+		}
+		exported++
+	}
+
+	slog.Info(fmt.Sprintf("Exported the outputs of %d of %d Workspace(s) to %s", exported, len(workspaces), dir))
+	return nil
+}
+
+// The values of the Workspace's current state outputs, by name
+func (c *Client) getOutputs(ctx context.Context, ws *tfe.Workspace) (map[string]any, error) {
+	list, err := c.StateVersionOutputs.ReadCurrent(ctx, ws.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := map[string]any{}
+	for _, o := range list.Items {
+		if o.Sensitive && o.Value == nil {
+			read, err := c.StateVersionOutputs.Read(ctx, o.ID)
+			if err != nil || read.Value == nil {
+				slog.Warn("skipping sensitive output, unable to read it", "workspace", ws.Name, "output", o.Name)
+				continue
+			}
+			o = read
+		}
+		outputs[o.Name] = o.Value
+	}
+	return outputs, nil
+}
+
+// Outputs as a JSON object, dotenv assignments (names upper-cased, non-strings as JSON), or tfvars
+func renderOutputs(outputs map[string]any, format string) ([]byte, error) {
+	if format == "json" {
+		b, err := json.MarshalIndent(outputs, "", "  ")
+		return append(b, '\n'), err
+	}
+
+	var names []string
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		encoded, err := json.Marshal(outputs[name])
+		if err != nil {
+			return nil, fmt.Errorf("output %s: %w", name, err)
+		}
+
+		switch format {
+		case "dotenv":
+			value := string(encoded)
+			if s, ok := outputs[name].(string); ok {
+				value = s
+			}
+			fmt.Fprintf(&b, "%s=%s\n", strings.ToUpper(dotenvUnsafe.ReplaceAllString(name, "_")), strconv.Quote(value))
+		case "tfvars":
+			// JSON values are valid HCL expressions
+			fmt.Fprintf(&b, "%s = %s\n", name, encoded)
+		}
+	}
+	return []byte(b.String()), nil
+}
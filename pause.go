@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// Returned instead of starting another operation once -max-runtime has passed
+var ErrMaxRuntime = errors.New("max runtime reached")
+
+// Lets an operator halt a batch between Workspaces, and resume it, by sending PAUSE_SIGNALS or pressing Enter
+type Pauser struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+	// Pressing Enter at the terminal toggles pausing too
+	keys bool
+	// When to stop starting operations, with -max-runtime
+	deadline time.Time
+}
+
+// Toggle pausing every time one of the PAUSE_SIGNALS arrives
+func newPauser() *Pauser {
+	p := &Pauser{resume: make(chan struct{})}
+	if len(PAUSE_SIGNALS) == 0 {
+		return p
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, PAUSE_SIGNALS...)
+	go func() {
+		for range signals {
+			p.toggle()
+		}
+	}()
+	return p
+}
+
+// Toggle pausing every time Enter is pressed between prompts, when stdin is a terminal
+func (p *Pauser) listenKeys() {
+	p.mu.Lock()
+	p.keys = true
+	p.mu.Unlock()
+	terminal.listen(func(string) { p.toggle() })
+}
+
+func (p *Pauser) toggle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused {
+		slog.Info("resuming")
+		close(p.resume)
+		p.resume = make(chan struct{})
+	} else {
+		switch {
+		case p.keys && len(PAUSE_SIGNALS) > 0:
+			slog.Warn("paused, press Enter or send the signal again to resume", "pid", os.Getpid())
+		case p.keys:
+			slog.Warn("paused, press Enter again to resume")
+		default:
+			slog.Warn("paused, send the signal again to resume", "pid", os.Getpid())
+		}
+	}
+	p.paused = !p.paused
+}
+
+// Block while paused; a nil Pauser never blocks
+func (p *Pauser) wait(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	if !p.deadline.IsZero() && time.Now().After(p.deadline) {
+		return ErrMaxRuntime
+	}
+
+	p.mu.Lock()
+	paused, resume := p.paused, p.resume
+	p.mu.Unlock()
+
+	if !paused {
+		return nil
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
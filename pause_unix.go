@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// e.g. kill -USR1 <pid>
+var PAUSE_SIGNALS = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// Windows has no user signals, so batches can only be paused from the terminal
+var PAUSE_SIGNALS = []os.Signal{}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Missing permissions already warned about, so composite actions and retries warn about each only once; with
+// -permission-cache they're remembered across invocations too, and not warned about again until the TTL passes
+type PermissionCache struct {
+	path string
+	ttl  time.Duration
+	// When each missing permission was last warned about, by action and what was missing it
+	Reported map[string]time.Time `json:"reported"`
+
+	// Warned about, or found in the cache, by this invocation
+	seen map[string]bool
+	// Found in the cache rather than warned about again
+	cached int
+}
+
+// Load the cache file, if there is one, dropping what's older than the TTL
+func openPermissionCache(path string, ttl time.Duration) (*PermissionCache, error) {
+	pc := &PermissionCache{path: path, ttl: ttl, Reported: map[string]time.Time{}, seen: map[string]bool{}}
+	if path == "" {
+		return pc, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, pc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for key, at := range pc.Reported {
+		if time.Since(at) > ttl {
+			delete(pc.Reported, key)
+		}
+	}
+	return pc, nil
+}
+
+// Whether the missing permission is new, and should be warned about
+func (pc *PermissionCache) report(action string, args []any) bool {
+	key := strings.TrimSpace(fmt.Sprintln(append([]any{action}, args...)...))
+	if pc.seen[key] {
+		return false
+	}
+	pc.seen[key] = true
+
+	if _, ok := pc.Reported[key]; ok {
+		pc.cached++
+		return false
+	}
+	pc.Reported[key] = time.Now().UTC()
+	return true
+}
+
+// Note what wasn't warned about again, and save the cache file if there is one
+func (c *Client) reportPermissions() {
+	pc := c.permissions
+	if pc.cached > 0 {
+		slog.Info(fmt.Sprintf("Not repeating %d missing permission(s) warned about in the last %s, see %s", pc.cached, pc.ttl, pc.path))
+	}
+	if pc.path == "" {
+		return
+	}
+
+	b, err := json.MarshalIndent(pc, "", "  ")
+	if err == nil {
+		err = os.WriteFile(pc.path, b, 0o644)
+	}
+	if err != nil {
+		slog.Error("unable to save permission cache", "path", pc.path, "error", err)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// Where a Workspace is in the apply pipeline
+type PipelineState string
+
+const (
+	StateSelected   PipelineState = "selected"
+	StateRunCreated PipelineState = "run-created"
+	StatePlanned    PipelineState = "planned"
+	StateConfirmed  PipelineState = "confirmed"
+	StateApplied    PipelineState = "applied"
+	StateFailed     PipelineState = "failed"
+)
+
+const pipelinePollInterval = 15 * time.Second
+
+// Run statuses where a plan finished without anything to apply
+var NOTHING_TO_APPLY_STATUSES = []tfe.RunStatus{tfe.RunPlannedAndFinished}
+
+// Run statuses where a Run will never get further
+var DEAD_STATUSES = []tfe.RunStatus{tfe.RunErrored, tfe.RunCanceled, tfe.RunDiscarded}
+
+// One Workspace's progress; its ID and Run are enough to resume it from a checkpoint
+type PipelineEntry struct {
+	WorkspaceID string        `json:"workspaceID"`
+	Workspace   string        `json:"workspace"`
+	State       PipelineState `json:"state"`
+	RunID       string        `json:"runID,omitempty"`
+	// Runs already created, including ones that failed and were retried
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ws *tfe.Workspace
+}
+
+// Every Workspace's progress, saved after each transition so an interrupted pipeline can be resumed
+type Checkpoint struct {
+	Organization string           `json:"organization"`
+	StartedAt    time.Time        `json:"startedAt"`
+	Entries      []*PipelineEntry `json:"entries"`
+}
+
+// How the scheduler drives the pipeline
+type PipelineOptions struct {
+	// Times a Run which failed to plan is started again
+	Retries int
+	// Stop starting Runs once any Workspace has failed, with -gate on-failure=stop
+	StopOnFailure bool
+	// Workspaces allowed between run-created and applied at once, unlimited if 0
+	Parallel int
+	// Confirm Runs even if their plan is stale, with -allow-stale
+	AllowStale bool
+}
+
+// Take each Workspace from selected through run-created, planned and confirmed to applied (or failed), all of
+// them advancing together, checkpointing every transition to the file
+func (c *Client) Apply(ctx context.Context, org, search string, assume bool, checkpointFile string, opts PipelineOptions) error {
+	cp, err := readCheckpoint(checkpointFile)
+	if err != nil {
+		return err
+	}
+
+	if cp != nil {
+		if cp.Organization != org {
+			return fmt.Errorf("checkpoint %s is for organization %s, not %s; delete it to start afresh", checkpointFile, cp.Organization, org)
+		}
+		slog.Info("resuming pipeline", "checkpoint", checkpointFile, "startedAt", cp.StartedAt)
+		for _, entry := range cp.Entries {
+			slog.Info("resuming", "workspace", entry.Workspace, "state", entry.State, "runID", entry.RunID)
+		}
+	} else {
+		workspaces, err := c.getWorkspaces(ctx, org, search)
+		if err != nil {
+			return err
+		}
+
+		cp = &Checkpoint{Organization: org, StartedAt: time.Now().UTC()}
+		var selected []*tfe.Workspace
+		for _, ws := range workspaces {
+			if !ws.Permissions.CanQueueRun {
+				c.missingPermission("workspace", ws.Name)
+				continue
+			}
+			slog.Info("can apply", "workspace", ws.Name)
+			cp.Entries = append(cp.Entries, &PipelineEntry{WorkspaceID: ws.ID, Workspace: ws.Name, State: StateSelected, ws: ws})
+			selected = append(selected, ws)
+		}
+		if err := c.estimateBatch(ctx, selected, "apply", opts.Parallel); err != nil {
+			return err
+		}
+	}
+
+	if !confirm(cp.pending(), assume) {
+		return nil
+	}
+
+	if err := writeCheckpoint(checkpointFile, cp); err != nil {
+		return err
+	}
+
+	for cp.pending() > 0 {
+		advanced := false
+		for _, entry := range cp.Entries {
+			from := entry.State
+			if err := c.advance(ctx, cp, entry, opts); err != nil {
+				return err
+			}
+			if entry.State != from {
+				advanced = true
+				entry.UpdatedAt = time.Now().UTC()
+				slog.Info("transition", "workspace", entry.Workspace, "from", from, "to", entry.State, "runID", entry.RunID, "error", entry.Error)
+				if err := writeCheckpoint(checkpointFile, cp); err != nil {
+					return err
+				}
+			}
+		}
+
+		if opts.StopOnFailure && cp.count(StateFailed) > 0 && cp.inFlight() == 0 {
+			return fmt.Errorf("%d Workspace(s) failed, stopping with %d not started", cp.count(StateFailed), cp.count(StateSelected))
+		}
+
+		if !advanced && cp.pending() > 0 {
+			slog.Info(fmt.Sprintf("Waiting for %d Workspace(s) in the pipeline", cp.pending()))
+			if err := sleep(ctx, pipelinePollInterval); err != nil {
+				return err
+			}
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Applied %d Workspace(s), %d failed", cp.count(StateApplied), cp.count(StateFailed)))
+	if cp.count(StateFailed) > 0 {
+		return fmt.Errorf("%d Workspace(s) failed, see %s", cp.count(StateFailed), checkpointFile)
+	}
+	return os.Remove(checkpointFile)
+}
+
+// Move the entry on by at most one state
+func (c *Client) advance(ctx context.Context, cp *Checkpoint, entry *PipelineEntry, opts PipelineOptions) error {
+	switch entry.State {
+	case StateSelected:
+		if opts.StopOnFailure && cp.count(StateFailed) > 0 {
+			return nil
+		}
+		if opts.Parallel > 0 && cp.inFlight() >= opts.Parallel {
+			return nil
+		}
+		ws, err := c.pipelineWorkspace(ctx, entry)
+		if err != nil {
+			return c.failed(entry, err)
+		}
+		run, err := c.createRun(ctx, ws)
+		if err != nil {
+			return c.failed(entry, err)
+		}
+		c.acted(ctx, "run", ws, run)
+		entry.RunID = run.ID
+		entry.Attempts++
+		entry.State = StateRunCreated
+
+	case StateRunCreated:
+		run, err := c.Runs.Read(ctx, entry.RunID)
+		if err != nil {
+			return c.failed(entry, err)
+		}
+		if state, reason := planTransition(run, entry.Attempts, opts.Retries); state != StateRunCreated {
+			if state == StateSelected {
+				slog.Warn("retrying, run failed", "workspace", entry.Workspace, "runID", run.ID, "status", run.Status, "attempt", entry.Attempts)
+			}
+			entry.State, entry.Error = state, reason
+		}
+
+	case StatePlanned:
+		ws, err := c.pipelineWorkspace(ctx, entry)
+		if err != nil {
+			return c.failed(entry, err)
+		}
+		run, err := c.Runs.Read(ctx, entry.RunID)
+		if err != nil {
+			return c.failed(entry, err)
+		}
+		passed, err := c.passesChecklist(ctx, ws, run)
+		if err != nil {
+			return c.failed(entry, err)
+		}
+		if !passed {
+			entry.Error = "checklist failed"
+			entry.State = StateFailed
+			return nil
+		}
+		if !c.canConfirm(ws.Name, run) {
+			entry.Error = "not confirmable"
+			entry.State = StateFailed
+			return nil
+		}
+		if !opts.AllowStale {
+			reason, err := c.stalePlan(ctx, ws, run)
+			if err != nil {
+				return c.failed(entry, err)
+			}
+			if reason != "" {
+				entry.Error = "stale plan, " + reason
+				entry.State = StateFailed
+				return nil
+			}
+		}
+		if err := c.pauser.wait(ctx); err != nil {
+			return err
+		}
+		if err := c.throttle(ctx); err != nil {
+			return err
+		}
+		if err := c.Runs.Apply(ctx, run.ID, tfe.RunApplyOptions{Comment: tfe.String(fmt.Sprintf("Confirmed by %s", c.annotation))}); err != nil {
+			return c.failed(entry, err)
+		}
+		c.acted(ctx, "confirm", ws, run)
+		entry.State = StateConfirmed
+
+	case StateConfirmed:
+		run, err := c.Runs.Read(ctx, entry.RunID)
+		if err != nil {
+			return c.failed(entry, err)
+		}
+		switch {
+		case run.Status == tfe.RunApplied:
+			entry.State = StateApplied
+		case slices.Contains(DEAD_STATUSES, run.Status):
+			entry.Error = fmt.Sprintf("run %s", run.Status)
+			entry.State = StateFailed
+		}
+	}
+
+	return nil
+}
+
+// Statuses of an apply already under way without the pipeline confirming it, e.g. with auto-apply
+var AUTO_APPLYING_STATUSES = []tfe.RunStatus{tfe.RunConfirmed, tfe.RunApplyQueued, tfe.RunApplying}
+
+// Where a Run in run-created goes next, and why if it failed: StateRunCreated to keep waiting for the plan,
+// StateSelected to start it again. Runs which plan and stop without being confirmable, e.g. awaiting a policy
+// override, fail rather than hold up the pipeline forever; ones auto-applied between polls are followed through
+func planTransition(run *tfe.Run, attempts, retries int) (PipelineState, string) {
+	switch {
+	case slices.Contains(NOTHING_TO_APPLY_STATUSES, run.Status) || run.Status == tfe.RunApplied:
+		return StateApplied, ""
+	case slices.Contains(AUTO_APPLYING_STATUSES, run.Status):
+		return StateConfirmed, ""
+	case run.Actions != nil && run.Actions.IsConfirmable:
+		return StatePlanned, ""
+	case run.Status == tfe.RunPolicySoftFailed:
+		return StateFailed, "policy check soft failed"
+	case slices.Contains(DEAD_STATUSES, run.Status):
+		if attempts <= retries {
+			return StateSelected, ""
+		}
+		return StateFailed, fmt.Sprintf("run %s", run.Status)
+	case slices.Contains(PLANNED_STATUSES, run.Status):
+		return StateFailed, fmt.Sprintf("run %s but not confirmable", run.Status)
+	}
+	return StateRunCreated, ""
+}
+
+// Fail the entry over an error -skip-errors allows, or any error acting on the Workspace; only errors which
+// stop the pipeline itself, like the context ending, are returned
+func (c *Client) failed(entry *PipelineEntry, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrMaxRuntime) {
+		return err
+	}
+	if tolerated := c.tolerate(entry.Workspace, err); tolerated == nil {
+		entry.Error = "skipped, " + errorKind(err)
+	} else {
+		entry.Error = err.Error()
+	}
+	entry.State = StateFailed
+	return nil
+}
+
+// The entry's Workspace, read again when resuming from a checkpoint
+func (c *Client) pipelineWorkspace(ctx context.Context, entry *PipelineEntry) (*tfe.Workspace, error) {
+	if entry.ws == nil {
+		ws, err := c.Workspaces.ReadByID(ctx, entry.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		entry.ws = ws
+	}
+	return entry.ws, nil
+}
+
+// Entries not yet applied or failed
+func (cp *Checkpoint) pending() int {
+	return len(cp.Entries) - cp.count(StateApplied) - cp.count(StateFailed)
+}
+
+// Entries with a Run which hasn't finished
+func (cp *Checkpoint) inFlight() int {
+	return cp.count(StateRunCreated) + cp.count(StatePlanned) + cp.count(StateConfirmed)
+}
+
+func (cp *Checkpoint) count(state PipelineState) int {
+	n := 0
+	for _, entry := range cp.Entries {
+		if entry.State == state {
+			n++
+		}
+	}
+	return n
+}
+
+// The checkpoint to resume, or nil if the file doesn't exist
+func readCheckpoint(path string) (*Checkpoint, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cp, nil
+}
+
+func writeCheckpoint(path string, cp *Checkpoint) error {
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+func TestPlanTransition(t *testing.T) {
+	confirmable := &tfe.RunActions{IsConfirmable: true}
+
+	tests := []struct {
+		name       string
+		run        *tfe.Run
+		attempts   int
+		wantState  PipelineState
+		wantReason string
+	}{
+		{"planning", &tfe.Run{Status: tfe.RunPlanning}, 1, StateRunCreated, ""},
+		{"pending", &tfe.Run{Status: tfe.RunPending}, 1, StateRunCreated, ""},
+		{"planned", &tfe.Run{Status: tfe.RunPlanned, Actions: confirmable}, 1, StatePlanned, ""},
+		{"policy checked", &tfe.Run{Status: tfe.RunPolicyChecked, Actions: confirmable}, 1, StatePlanned, ""},
+		{"nothing to apply", &tfe.Run{Status: tfe.RunPlannedAndFinished}, 1, StateApplied, ""},
+		{"auto-applied", &tfe.Run{Status: tfe.RunApplied}, 1, StateApplied, ""},
+		{"auto-applying", &tfe.Run{Status: tfe.RunApplying}, 1, StateConfirmed, ""},
+		{"auto-apply queued", &tfe.Run{Status: tfe.RunApplyQueued}, 1, StateConfirmed, ""},
+		{"auto-confirmed", &tfe.Run{Status: tfe.RunConfirmed}, 1, StateConfirmed, ""},
+		{"soft failed", &tfe.Run{Status: tfe.RunPolicySoftFailed}, 1, StateFailed, "policy check soft failed"},
+		{"awaiting override", &tfe.Run{Status: tfe.RunPolicyOverride}, 1, StateFailed, "run policy_override but not confirmable"},
+		{"planned without permission", &tfe.Run{Status: tfe.RunPlanned, Actions: &tfe.RunActions{}}, 1, StateFailed, "run planned but not confirmable"},
+		{"errored with retries left", &tfe.Run{Status: tfe.RunErrored}, 2, StateSelected, ""},
+		{"errored out of retries", &tfe.Run{Status: tfe.RunErrored}, 3, StateFailed, "run errored"},
+		{"discarded out of retries", &tfe.Run{Status: tfe.RunDiscarded}, 3, StateFailed, "run discarded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, reason := planTransition(tt.run, tt.attempts, 2)
+			if state != tt.wantState || reason != tt.wantReason {
+				t.Errorf("planTransition() = %s, %q, want %s, %q", state, reason, tt.wantState, tt.wantReason)
+			}
+		})
+	}
+}
+
+// Gated entries wait in selected without any request being made
+func TestAdvanceGatesSelected(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  PipelineOptions
+		other PipelineState
+	}{
+		{"stopped on failure", PipelineOptions{StopOnFailure: true}, StateFailed},
+		{"parallel limit", PipelineOptions{Parallel: 1}, StateRunCreated},
+		{"parallel limit while planned", PipelineOptions{Parallel: 1}, StatePlanned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &PipelineEntry{Workspace: "dev", State: StateSelected}
+			cp := &Checkpoint{Entries: []*PipelineEntry{{Workspace: "prod", State: tt.other}, entry}}
+			if err := (&Client{}).advance(context.Background(), cp, entry, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if entry.State != StateSelected || entry.Attempts != 0 {
+				t.Errorf("advance() moved the entry to %s after %d attempt(s)", entry.State, entry.Attempts)
+			}
+		})
+	}
+}
+
+func TestCheckpointCounts(t *testing.T) {
+	cp := &Checkpoint{}
+	for _, state := range []PipelineState{StateSelected, StateRunCreated, StatePlanned, StateConfirmed, StateApplied, StateFailed, StateApplied} {
+		cp.Entries = append(cp.Entries, &PipelineEntry{State: state})
+	}
+	if got := cp.pending(); got != 4 {
+		t.Errorf("pending() = %d, want 4", got)
+	}
+	if got := cp.inFlight(); got != 3 {
+		t.Errorf("inFlight() = %d, want 3", got)
+	}
+	if got := cp.count(StateApplied); got != 2 {
+		t.Errorf("count(applied) = %d, want 2", got)
+	}
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	missing, err := readCheckpoint(path)
+	if err != nil || missing != nil {
+		t.Fatalf("readCheckpoint() of a missing file = %v, %v, want nil, nil", missing, err)
+	}
+
+	now := time.Date(2026, 10, 13, 10, 0, 0, 0, time.UTC)
+	cp := &Checkpoint{
+		Organization: "myOrg",
+		StartedAt:    now,
+		Entries: []*PipelineEntry{
+			{WorkspaceID: "ws-1", Workspace: "dev", State: StateRunCreated, RunID: "run-1", Attempts: 1, UpdatedAt: now},
+			{WorkspaceID: "ws-2", Workspace: "prod", State: StateFailed, Attempts: 3, Error: "run errored", UpdatedAt: now},
+		},
+	}
+	if err := writeCheckpoint(path, cp); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cp) {
+		t.Errorf("readCheckpoint() = %+v, want %+v", got, cp)
+	}
+}
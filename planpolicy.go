@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Rego rules producing a message for each reason to deny the plan, as conftest uses
+const defaultPlanPolicyQuery = "data.terraform.deny"
+
+// A Check that the Rego policy file denies nothing in the plan JSON, from -plan-policy
+func newPlanPolicyCheck(path string) (*Check, error) {
+	check := &Check{Name: "plan policy", PlanPolicy: path}
+	if err := check.parsePlanPolicy(); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// The policy must exist and the opa binary be installed before any Run is evaluated
+func (check *Check) parsePlanPolicy() error {
+	if _, err := os.Stat(check.PlanPolicy); err != nil {
+		return err
+	}
+	if check.Query == "" {
+		check.Query = defaultPlanPolicyQuery
+	}
+	if _, err := exec.LookPath("opa"); err != nil {
+		return fmt.Errorf("plan policies are evaluated with opa: %w", err)
+	}
+	return nil
+}
+
+// Evaluate the policy's query with the Run's plan JSON as input, it passes if the query is undefined or empty
+func (c *Client) evaluatePlanPolicy(ctx context.Context, check *Check, run *tfe.Run) (CheckResult, error) {
+	result := CheckResult{Name: check.Name}
+	if run.Plan == nil {
+		result.Detail = "no plan"
+		return result, nil
+	}
+
+	plan, err := c.Plans.ReadJSONOutput(ctx, run.Plan.ID)
+	if err != nil {
+		return result, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "opa", "eval", "--format", "json", "--stdin-input", "--data", check.PlanPolicy, check.Query)
+	cmd.Stdin = bytes.NewReader(plan)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return result, fmt.Errorf("opa eval %s: %w: %s", check.PlanPolicy, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var output struct {
+		Result []struct {
+			Expressions []struct {
+				Value any `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return result, fmt.Errorf("opa eval %s: %w", check.PlanPolicy, err)
+	}
+
+	var denied []string
+	for _, r := range output.Result {
+		for _, expression := range r.Expressions {
+			denied = append(denied, denials(expression.Value)...)
+		}
+	}
+
+	result.Passed = len(denied) == 0
+	result.Detail = fmt.Sprintf("%d denial(s)", len(denied))
+	if len(denied) > 0 {
+		result.Detail += ": " + strings.Join(denied, "; ")
+	}
+	return result, nil
+}
+
+// The messages in a query's value: a set of them, a single one, or true
+func denials(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case bool:
+		if v {
+			return []string{"denied"}
+		}
+		return nil
+	case string:
+		return []string{v}
+	case []any:
+		var messages []string
+		for _, item := range v {
+			messages = append(messages, denials(item)...)
+		}
+		return messages
+	default:
+		b, _ := json.Marshal(v)
+		return []string{string(b)}
+	}
+}
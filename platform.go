@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Optional platform features the tool depends on
+const (
+	FeatureAssessments    = "assessments"
+	FeatureCostEstimation = "cost-estimation"
+	FeatureProjects       = "projects"
+	FeaturePolicyChecks   = "policy-checks"
+	FeatureAgents         = "agents"
+)
+
+// The entitlement each paid feature requires, organizations on free or standard tiers lack some of these
+var FEATURE_ENTITLEMENTS = map[string]string{
+	FeatureAssessments:    "assessments",
+	FeatureCostEstimation: "cost-estimation",
+	FeaturePolicyChecks:   "sentinel",
+	FeatureAgents:         "agents",
+}
+
+// What the tool is talking to, detected at startup
+type Platform struct {
+	Name       string
+	Version    string
+	APIVersion string
+	// Features which were detected, anything missing is assumed to be supported
+	Features map[string]bool
+	// The organization's entitlement set, cached for the invocation
+	Entitlements map[string]bool
+	// Features already noted as skipped, so the note is only logged once
+	noted map[string]bool
+}
+
+func (p *Platform) IsEnterprise() bool {
+	return p.Name != "" && p.Name != "Terraform Cloud"
+}
+
+// Detect the platform and which features it supports for the organization, so actions can skip
+// unsupported features up front instead of failing part way through a batch
+func (c *Client) detectPlatform(ctx context.Context, org string) {
+	c.platform = &Platform{
+		APIVersion:   c.RemoteAPIVersion(),
+		Features:     map[string]bool{},
+		Entitlements: map[string]bool{},
+		noted:        map[string]bool{},
+	}
+
+	if err := c.detectApp(ctx); err != nil {
+		slog.Debug("unable to detect platform", "error", err)
+	}
+
+	attrs, err := c.getRawAttributes(ctx, fmt.Sprintf("organizations/%s", url.PathEscape(org)))
+	if err != nil {
+		slog.Debug("unable to detect organization features", "error", err)
+	} else {
+		// Older releases don't know about assessments at all, so the attribute is missing entirely
+		_, ok := attrs["assessments-enforced"]
+		c.platform.Features[FeatureAssessments] = ok
+		enabled, _ := attrs["cost-estimation-enabled"].(bool)
+		c.platform.Features[FeatureCostEstimation] = enabled
+	}
+
+	// Read raw so that entitlements newer than go-tfe, like assessments, are included
+	ent, err := c.getRawAttributes(ctx, fmt.Sprintf("organizations/%s/entitlement-set", url.PathEscape(org)))
+	if err != nil {
+		slog.Debug("unable to read entitlements", "error", err)
+	}
+	for k, v := range ent {
+		if entitled, ok := v.(bool); ok {
+			c.platform.Entitlements[k] = entitled
+		}
+	}
+
+	req, err := c.NewRequest("GET", fmt.Sprintf("organizations/%s/projects", url.PathEscape(org)), &tfe.ListOptions{PageSize: 1})
+	if err == nil {
+		err = req.Do(ctx, &bytes.Buffer{})
+		if err == nil {
+			c.platform.Features[FeatureProjects] = true
+		} else if errors.Is(err, tfe.ErrResourceNotFound) {
+			c.platform.Features[FeatureProjects] = false
+		}
+	}
+
+	args := []any{"name", c.platform.Name, "apiVersion", c.platform.APIVersion}
+	if c.platform.Version != "" {
+		args = append(args, "version", c.platform.Version)
+	}
+	slog.Info("platform", args...)
+	for _, feature := range []string{FeatureAssessments, FeatureCostEstimation, FeatureProjects, FeaturePolicyChecks, FeatureAgents} {
+		if !c.supports(feature) {
+			slog.Info("feature unavailable", "feature", feature, "organization", org)
+		}
+	}
+}
+
+// Whether a feature is available and entitled, assuming it is when detection was not possible
+func (c *Client) supports(feature string) bool {
+	if c.platform == nil {
+		return true
+	}
+	if supported, ok := c.platform.Features[feature]; ok && !supported {
+		return false
+	}
+	if entitled, ok := c.platform.Entitlements[FEATURE_ENTITLEMENTS[feature]]; ok && !entitled {
+		return false
+	}
+	return true
+}
+
+// Report whether something depending on the feature should be skipped, noting why the first time
+func (c *Client) skipUnsupported(feature, what string) bool {
+	if c.supports(feature) {
+		return false
+	}
+
+	if !c.platform.noted[feature] {
+		slog.Warn(fmt.Sprintf("skipping %s, %s is not available to this organization", what, feature))
+		c.platform.noted[feature] = true
+	}
+	return true
+}
+
+// The unauthenticated ping endpoint identifies the application and, on Terraform Enterprise, its release
+func (c *Client) detectApp(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(tfeAddress(), "/")+tfe.DefaultBasePath+"ping", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	c.platform.Name = resp.Header.Get("TFP-AppName")
+	c.platform.Version = resp.Header.Get("X-TFE-Version")
+	if c.platform.Name == "" {
+		if strings.Contains(tfeAddress(), "app.terraform.io") {
+			c.platform.Name = "Terraform Cloud"
+		} else {
+			c.platform.Name = "Terraform Enterprise"
+		}
+	}
+
+	return nil
+}
+
+// Read the raw JSON:API attributes of a resource, to detect attributes go-tfe doesn't model
+func (c *Client) getRawAttributes(ctx context.Context, path string) (map[string]any, error) {
+	r, err := c.getRawResource(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return r.Attributes, nil
+}
+
+// A resource as the API returned it, with the attributes and relationships go-tfe may not model
+type rawResource struct {
+	Attributes map[string]any `json:"attributes"`
+	jsonapiResource
+}
+
+func (c *Client) getRawResource(ctx context.Context, path string) (*rawResource, error) {
+	req, err := c.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &bytes.Buffer{}
+	if err := req.Do(ctx, body); err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Data rawResource `json:"data"`
+	}
+	if err := json.Unmarshal(body.Bytes(), &doc); err != nil {
+		return nil, err
+	}
+
+	return &doc.Data, nil
+}
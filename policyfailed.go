@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Whether any of the Run's policy checks hard-failed, so it can never be applied
+func (c *Client) policyHardFailed(ctx context.Context, run *tfe.Run) (bool, error) {
+	if !c.supports(FeaturePolicyChecks) || !run.Actions.IsDiscardable {
+		return false, nil
+	}
+
+	pcList, err := c.PolicyChecks.List(ctx, run.ID, nil)
+	if err != nil {
+		return false, err
+	}
+	for _, pc := range pcList.Items {
+		if pc.Status == tfe.PolicyHardFailed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Discard a Run blocked by a hard-mandatory policy, then with requeue only queue a fresh Run once it's discarded,
+// for when the configuration or policy has since been fixed
+func (c *Client) discardPolicyFailed(ctx context.Context, t target, requeue bool) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+
+	slog.Info("discarding, policy hard-failed", "workspace", t.ws.Name, "runID", t.run.ID)
+	if err := c.Runs.Discard(ctx, t.run.ID, tfe.RunDiscardOptions{
+		Comment: tfe.String(fmt.Sprintf("Hard-mandatory policy failed, discarded by %s", c.annotation)),
+	}); err != nil {
+		return c.tolerate(t.ws.Name, err)
+	}
+
+	if !requeue {
+		c.acted(ctx, "discard", t.ws, t.run)
+		c.addReceipt(ctx, "discard", t)
+		return nil
+	}
+
+	run, err := c.createRun(ctx, t.ws)
+	if err != nil {
+		// Nothing replaced the discarded Run, so the receipt has to
+		c.addReceipt(ctx, "discard", t)
+		return fmt.Errorf("%s: discarded %s but unable to queue a new run: %w", t.ws.Name, t.run.ID, err)
+	}
+
+	slog.Info("requeued", "workspace", t.ws.Name, "discardedRunID", t.run.ID, "runID", run.ID)
+	c.acted(ctx, "requeue", t.ws, run)
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+const (
+	SourceWorkspace   = "workspace"
+	SourceVariableSet = "variable set"
+	SourceGlobalSet   = "global variable set"
+)
+
+// Where each Variable's value comes from on each Workspace, to find values shadowed by another source
+type VariablePrecedenceReport struct {
+	Organization string                 `json:"organization"`
+	Search       string                 `json:"search,omitempty"`
+	Workspaces   []*WorkspacePrecedence `json:"workspaces"`
+	// Variables with more than one source, across every Workspace
+	Shadowed int `json:"shadowed"`
+}
+
+type WorkspacePrecedence struct {
+	Workspace string               `json:"workspace"`
+	Variables []*EffectiveVariable `json:"variables"`
+}
+
+// The value a Run gets for a key, and the values it overrides
+type EffectiveVariable struct {
+	Key      string            `json:"key"`
+	Category string            `json:"category"`
+	Source   VariableSource    `json:"source"`
+	Shadowed []*VariableSource `json:"shadowed,omitempty"`
+}
+
+type VariableSource struct {
+	Kind        string `json:"kind"`
+	VariableSet string `json:"variableSet,omitempty"`
+	// Sensitive values are hashed, or "(sensitive)" when the API doesn't return them
+	Value string `json:"value"`
+}
+
+// Report the effective source of every Variable (or only -var-key) on the Workspace(s). Workspace Variables win,
+// then Variable Sets applied to the Workspace, then global ones, and between Sets of the same scope the name which
+// sorts first. go-tfe v1.10.0 doesn't model Project-scoped Variable Sets, so they aren't considered
+func (c *Client) VarPrecedence(ctx context.Context, org, search, key, reportFile string) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	varSets, err := c.getVariableSets(ctx, org)
+	if err != nil {
+		return err
+	}
+
+	report := &VariablePrecedenceReport{Organization: org, Search: search, Workspaces: []*WorkspacePrecedence{}}
+	for _, ws := range workspaces {
+		variables, err := c.getVariables(ctx, ws.ID)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Every source of each key, in precedence order
+		sources := map[variableID][]*VariableSource{}
+		var ids []variableID
+		add := func(key string, category tfe.CategoryType, source *VariableSource) {
+			id := variableID{key, string(category)}
+			if _, ok := sources[id]; !ok {
+				ids = append(ids, id)
+			}
+			sources[id] = append(sources[id], source)
+		}
+
+		for _, v := range variables {
+			if key == "" || v.Key == key {
+				add(v.Key, v.Category, &VariableSource{Kind: SourceWorkspace, Value: reportedValue(v.Value, v.Sensitive)})
+			}
+		}
+		for _, vs := range appliedVariableSets(varSets, ws) {
+			kind := SourceVariableSet
+			if vs.Global {
+				kind = SourceGlobalSet
+			}
+			for _, v := range vs.Variables {
+				if key == "" || v.Key == key {
+					add(v.Key, v.Category, &VariableSource{Kind: kind, VariableSet: vs.Name, Value: reportedValue(v.Value, v.Sensitive)})
+				}
+			}
+		}
+
+		wp := &WorkspacePrecedence{Workspace: ws.Name, Variables: []*EffectiveVariable{}}
+		sort.Slice(ids, func(i, j int) bool {
+			if ids[i].key != ids[j].key {
+				return ids[i].key < ids[j].key
+			}
+			return ids[i].category < ids[j].category
+		})
+		for _, id := range ids {
+			found := sources[id]
+			ev := &EffectiveVariable{Key: id.key, Category: id.category, Source: *found[0], Shadowed: found[1:]}
+			if len(ev.Shadowed) > 0 {
+				report.Shadowed++
+				for _, shadowed := range ev.Shadowed {
+					slog.Warn("shadowed", "workspace", ws.Name, "key", id.key, "category", id.category, "source", ev.Source.Kind, "variableSet", ev.Source.VariableSet, "shadowedSource", shadowed.Kind, "shadowedVariableSet", shadowed.VariableSet)
+				}
+			}
+			wp.Variables = append(wp.Variables, ev)
+		}
+		report.Workspaces = append(report.Workspaces, wp)
+	}
+
+	slog.Info(fmt.Sprintf("Found %d shadowed Variable(s) across %d Workspace(s)", report.Shadowed, len(report.Workspaces)))
+	return c.writeJSONReport(reportFile, report)
+}
+
+// The Variable Sets which apply to the Workspace, in precedence order
+func appliedVariableSets(varSets []*tfe.VariableSet, ws *tfe.Workspace) []*tfe.VariableSet {
+	var applied []*tfe.VariableSet
+	for _, vs := range varSets {
+		if vs.Global {
+			applied = append(applied, vs)
+			continue
+		}
+		for _, w := range vs.Workspaces {
+			if w.ID == ws.ID {
+				applied = append(applied, vs)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(applied, func(i, j int) bool {
+		if applied[i].Global != applied[j].Global {
+			return !applied[i].Global
+		}
+		return applied[i].Name < applied[j].Name
+	})
+	return applied
+}
+
+// Terraform and environment Variables with the same key don't shadow each other
+type variableID struct {
+	key      string
+	category string
+}
+
+// Every Variable Set in the Organization, with the Workspaces it's applied to and its Variables
+func (c *Client) getVariableSets(ctx context.Context, org string) ([]*tfe.VariableSet, error) {
+	var varSets []*tfe.VariableSet
+
+	n := 0
+	for {
+		opts := &tfe.VariableSetListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+			Include: fmt.Sprintf("%s,%s", tfe.VariableSetWorkspaces, tfe.VariableSetVars),
+		}
+
+		vsList, err := c.VariableSets.List(ctx, org, opts)
+		if err != nil {
+			return varSets, err
+		}
+
+		varSets = append(varSets, vsList.Items...)
+
+		if vsList.NextPage > n {
+			n = vsList.NextPage
+		} else {
+			return varSets, nil
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Log messages which announce a change the confirmation prompt is about to make, rather than a decision not to
+var PREVIEW_PREFIXES = []string{"can ", "will create", "will update", "will delete", "will unmute", "will unlock"}
+
+// The changes announced since the last confirmation prompt, shown before it with -preview, nil without
+var preview *selectionPreview
+
+type selectionPreview struct {
+	// Changes shown per page of an interactive prompt
+	pageSize int
+
+	mu      sync.Mutex
+	changes []previewChange
+	// The selected Workspaces by name, and their Projects by ID if the platform has them
+	workspaces map[string]*tfe.Workspace
+	projects   map[string]string
+}
+
+type previewChange struct {
+	workspace string
+	runID     string
+	change    string
+}
+
+// Collect the changes each action announces, to list them before its confirmation prompt
+func previewChanges(pageSize int) {
+	preview = &selectionPreview{pageSize: pageSize, workspaces: map[string]*tfe.Workspace{}}
+	announceTo(preview.add)
+}
+
+// Pass the changes each action announces to add as well as logging them
+func announceTo(add func(previewChange)) {
+	addLogHandler(&announcedHandler{add: add})
+}
+
+func (p *selectionPreview) add(change previewChange) {
+	p.mu.Lock()
+	p.changes = append(p.changes, change)
+	p.mu.Unlock()
+}
+
+// Remember the selected Workspace(s), and the Project each is in, to count the changes by Project and tag
+func (c *Client) previewWorkspaces(ctx context.Context, org string, workspaces []*tfe.Workspace) error {
+	if preview == nil {
+		return nil
+	}
+
+	var projects map[string]string
+	if preview.projects == nil && c.supports(FeatureProjects) {
+		var err error
+		if projects, err = c.getProjectNames(ctx, org); err != nil {
+			return err
+		}
+	}
+
+	preview.mu.Lock()
+	defer preview.mu.Unlock()
+	for _, ws := range workspaces {
+		preview.workspaces[ws.Name] = ws
+	}
+	if projects != nil {
+		preview.projects = projects
+	}
+	return nil
+}
+
+// Print the changes, counted by Project and tag, then list them a page at a time if the operator is prompted
+func (p *selectionPreview) show(w io.Writer, interactive bool) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	changes := p.changes
+	p.changes = nil
+	p.mu.Unlock()
+	if len(changes) == 0 {
+		return
+	}
+
+	byProject, byTag := map[string]int{}, map[string]int{}
+	counted := map[string]bool{}
+	for _, change := range changes {
+		if counted[change.workspace] {
+			continue
+		}
+		counted[change.workspace] = true
+
+		ws := p.workspaces[change.workspace]
+		if ws == nil {
+			continue
+		}
+		if p.projects != nil {
+			byProject[p.projects[ws.ID]]++
+		}
+		for _, tag := range ws.TagNames {
+			byTag[tag]++
+		}
+	}
+
+	fmt.Fprintf(w, "Preview: %d change(s) on %d Workspace(s)\n", len(changes), len(counted))
+	printPreviewCounts(w, "By project", byProject)
+	printPreviewCounts(w, "By tag", byTag)
+
+	for start := 0; start < len(changes); start += p.pageSize {
+		end := min(start+p.pageSize, len(changes))
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  #\tWORKSPACE\tRUN\tCHANGE")
+		for idx, change := range changes[start:end] {
+			fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n", start+idx+1, change.workspace, change.runID, change.change)
+		}
+		tw.Flush()
+
+		if !interactive || end == len(changes) {
+			continue
+		}
+		fmt.Fprintf(w, "-- %d-%d of %d, Enter for more or q to stop listing -- ", start+1, end, len(changes))
+		input, err := readLine()
+		if err != nil || strings.TrimSpace(input) == "q" {
+			break
+		}
+	}
+}
+
+func printPreviewCounts(w io.Writer, title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintf(w, "%s:\n", title)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		name := key
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Fprintf(tw, "  %s\t%d\n", name, counts[key])
+	}
+	tw.Flush()
+}
+
+// Passes on announced changes with a workspace attribute, to the preview or the quorum
+type announcedHandler struct {
+	attrs []slog.Attr
+	add   func(previewChange)
+}
+
+func (h *announcedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level == slog.LevelInfo
+}
+
+func (h *announcedHandler) Handle(ctx context.Context, r slog.Record) error {
+	announced := false
+	for _, prefix := range PREVIEW_PREFIXES {
+		if strings.HasPrefix(r.Message, prefix) {
+			announced = true
+		}
+	}
+	if !announced {
+		return nil
+	}
+
+	change := previewChange{change: r.Message}
+	add := func(a slog.Attr) bool {
+		switch a.Key {
+		case "workspace":
+			change.workspace = a.Value.String()
+		case "runID":
+			change.runID = a.Value.String()
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+	if change.workspace == "" {
+		return nil
+	}
+
+	h.add(change)
+	return nil
+}
+
+func (h *announcedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &announcedHandler{attrs: append(append([]slog.Attr{}, h.attrs...), attrs...), add: h.add}
+}
+
+// The tool doesn't use groups, so records are previewed as if there were none
+func (h *announcedHandler) WithGroup(name string) slog.Handler {
+	return h
+}
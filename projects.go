@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// go-tfe v1.10.0 doesn't model Projects, so they're read through the raw API
+type Project struct {
+	ID   string `jsonapi:"primary,projects"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type projectList struct {
+	*tfe.Pagination
+	Items []*Project
+}
+
+// Filters the Organization's Workspaces to those in a Project
+type projectWorkspaceListOptions struct {
+	tfe.ListOptions
+	ProjectID string `url:"filter[project][id]"`
+}
+
+// The name of the Project each of the Organization's Workspaces is in, by Workspace ID
+func (c *Client) getProjectNames(ctx context.Context, org string) (map[string]string, error) {
+	if !c.supports(FeatureProjects) {
+		return nil, fmt.Errorf("projects are not supported by %s", c.platform.Name)
+	}
+
+	projects, err := c.getProjects(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	for _, project := range projects {
+		n := 0
+		for {
+			opts := &projectWorkspaceListOptions{
+				ListOptions: tfe.ListOptions{
+					PageNumber: n,
+				},
+				ProjectID: project.ID,
+			}
+
+			req, err := c.NewRequest("GET", fmt.Sprintf("organizations/%s/workspaces", url.PathEscape(org)), opts)
+			if err != nil {
+				return nil, err
+			}
+
+			wsList := &tfe.WorkspaceList{}
+			if err := req.Do(ctx, wsList); err != nil {
+				return nil, err
+			}
+
+			for _, ws := range wsList.Items {
+				names[ws.ID] = project.Name
+			}
+
+			if wsList.NextPage > n {
+				n = wsList.NextPage
+			} else {
+				break
+			}
+		}
+	}
+
+	return names, nil
+}
+
+func (c *Client) getProjects(ctx context.Context, org string) ([]*Project, error) {
+	var projects []*Project
+
+	n := 0
+	for {
+		opts := &tfe.ListOptions{
+			PageNumber: n,
+		}
+
+		req, err := c.NewRequest("GET", fmt.Sprintf("organizations/%s/projects", url.PathEscape(org)), opts)
+		if err != nil {
+			return projects, err
+		}
+
+		projectList := &projectList{}
+		if err := req.Do(ctx, projectList); err != nil {
+			return projects, err
+		}
+
+		projects = append(projects, projectList.Items...)
+
+		if projectList.NextPage > n {
+			n = projectList.NextPage
+		} else {
+			return projects, nil
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// One step of a -query, e.g. ".workspaces", "[]", "[0]" or "| keys"
+type querySegment struct {
+	field   string
+	index   int
+	iterate bool
+	fn      string
+}
+
+// Evaluate a jq-like query over a JSON document. Supported are paths (.a.b, .["a b"]),
+// array indexes ([0]), iteration ([]), pipes, and the keys and length functions.
+func evalQuery(doc []byte, query string) ([]any, error) {
+	segments, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var root any
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+
+	values := []any{root}
+	for _, seg := range segments {
+		var next []any
+		for _, v := range values {
+			out, err := seg.apply(v)
+			if err != nil {
+				return nil, fmt.Errorf("query %q: %w", query, err)
+			}
+			next = append(next, out...)
+		}
+		values = next
+	}
+
+	return values, nil
+}
+
+// Render query results one per line, strings unquoted so they can be used directly in shell
+func formatQueryResults(values []any) (string, error) {
+	var b strings.Builder
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			b.WriteString(s)
+		} else {
+			out, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			b.Write(out)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+func parseQuery(query string) ([]querySegment, error) {
+	var segments []querySegment
+
+	for _, part := range strings.Split(query, "|") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "keys", "length":
+			segments = append(segments, querySegment{fn: part})
+			continue
+		case ".":
+			continue
+		}
+
+		if !strings.HasPrefix(part, ".") && !strings.HasPrefix(part, "[") {
+			return nil, fmt.Errorf("query %q: unexpected %q", query, part)
+		}
+
+		for i := 0; i < len(part); {
+			switch part[i] {
+			case '.':
+				i++
+				start := i
+				for i < len(part) && isQueryIdent(part[i]) {
+					i++
+				}
+				if i > start {
+					segments = append(segments, querySegment{field: part[start:i]})
+				}
+			case '[':
+				end := strings.IndexByte(part[i:], ']')
+				if end < 0 {
+					return nil, fmt.Errorf("query %q: unterminated [", query)
+				}
+				inner := part[i+1 : i+end]
+				i += end + 1
+
+				switch {
+				case inner == "":
+					segments = append(segments, querySegment{iterate: true})
+				case strings.HasPrefix(inner, `"`):
+					field, err := strconv.Unquote(inner)
+					if err != nil {
+						return nil, fmt.Errorf("query %q: invalid key %s", query, inner)
+					}
+					segments = append(segments, querySegment{field: field})
+				default:
+					idx, err := strconv.Atoi(inner)
+					if err != nil {
+						return nil, fmt.Errorf("query %q: invalid index %s", query, inner)
+					}
+					segments = append(segments, querySegment{fn: "index", index: idx})
+				}
+			default:
+				return nil, fmt.Errorf("query %q: unexpected %q", query, part[i:])
+			}
+		}
+	}
+
+	return segments, nil
+}
+
+func (seg querySegment) apply(v any) ([]any, error) {
+	switch {
+	case seg.fn == "keys":
+		switch t := v.(type) {
+		case map[string]any:
+			keys := maps.Keys(t)
+			sort.Strings(keys)
+			out := make([]any, len(keys))
+			for idx, k := range keys {
+				out[idx] = k
+			}
+			return []any{out}, nil
+		case []any:
+			out := make([]any, len(t))
+			for idx := range t {
+				out[idx] = idx
+			}
+			return []any{out}, nil
+		}
+		return nil, fmt.Errorf("cannot take keys of %T", v)
+	case seg.fn == "length":
+		switch t := v.(type) {
+		case map[string]any:
+			return []any{len(t)}, nil
+		case []any:
+			return []any{len(t)}, nil
+		case string:
+			return []any{len(t)}, nil
+		case nil:
+			return []any{0}, nil
+		}
+		return nil, fmt.Errorf("cannot take length of %T", v)
+	case seg.fn == "index":
+		switch t := v.(type) {
+		case []any:
+			idx := seg.index
+			if idx < 0 {
+				idx += len(t)
+			}
+			if idx < 0 || idx >= len(t) {
+				return []any{nil}, nil
+			}
+			return []any{t[idx]}, nil
+		case nil:
+			return []any{nil}, nil
+		}
+		return nil, fmt.Errorf("cannot index %T with a number", v)
+	case seg.iterate:
+		switch t := v.(type) {
+		case []any:
+			return t, nil
+		case map[string]any:
+			keys := maps.Keys(t)
+			sort.Strings(keys)
+			out := make([]any, len(keys))
+			for idx, k := range keys {
+				out[idx] = t[k]
+			}
+			return out, nil
+		}
+		return nil, fmt.Errorf("cannot iterate over %T", v)
+	default:
+		switch t := v.(type) {
+		case map[string]any:
+			return []any{t[seg.field]}, nil
+		case nil:
+			return []any{nil}, nil
+		}
+		return nil, fmt.Errorf("cannot index %T with %q", v, seg.field)
+	}
+}
+
+func isQueryIdent(b byte) bool {
+	return b == '_' || b == '-' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
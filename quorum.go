@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The approvals confirming a large batch needs from distinct operators, nil without -quorum
+var batchQuorum *Quorum
+
+type Quorum struct {
+	batchID string
+	// What's being confirmed, which approvals must name: set once the Organization is known and as each action
+	// starts
+	organization string
+	action       string
+	// How many distinct operators must approve
+	required int
+	// Batches of more changes than this need the quorum, smaller ones are confirmed as usual
+	threshold int
+	// Each operator's Ed25519 public key, by name
+	keys map[string]ed25519.PublicKey
+	// A directory of approval files, or an approvals API returning them as a JSON list
+	approvals string
+
+	mu sync.Mutex
+	// The changes announced since the last confirmation prompt, whose digest approvals sign
+	changes []previewChange
+}
+
+// An operator's approval of a batch, signing quorumMessage with their Ed25519 key
+type QuorumApproval struct {
+	Operator     string `json:"operator"`
+	BatchID      string `json:"batchID"`
+	Organization string `json:"organization"`
+	Action       string `json:"action"`
+	Changes      int    `json:"changes"`
+	// The hex SHA-256 of the workspace/run of each change, sorted, one per line
+	Digest    string    `json:"digest"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Signature string    `json:"signature"`
+}
+
+// What operators sign to approve the batch, so an approval can't be replayed for another batch, Organization,
+// action or set of Runs, or once it's expired
+func quorumMessage(a *QuorumApproval) string {
+	return fmt.Sprintf("go-tfe-bulk quorum\n%s\n%s\n%s\n%d\n%s\n%s\n", a.BatchID, a.Organization, a.Action, a.Changes, a.Digest, a.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// The digest of the announced changes; unchanged by the order they were announced in
+func quorumDigest(changes []previewChange) string {
+	ids := make([]string, 0, len(changes))
+	for _, change := range changes {
+		ids = append(ids, change.workspace+"/"+change.runID)
+	}
+	sort.Strings(ids)
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Require approvals from required distinct operators, whose public keys are the PEM files (<operator>.pem) in
+// keysDir, for batches of more than threshold changes
+func requireQuorum(batchID string, required, threshold int, keysDir, approvals string) error {
+	if batchID == "" {
+		return errors.New("-quorum requires -batch-id, for operators to approve")
+	}
+	if keysDir == "" || approvals == "" {
+		return errors.New("-quorum requires -quorum-keys and -quorum-approvals")
+	}
+
+	q := &Quorum{batchID: batchID, required: required, threshold: threshold, keys: map[string]ed25519.PublicKey{}, approvals: approvals}
+	paths, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		key, err := openQuorumKey(path)
+		if err != nil {
+			return err
+		}
+		q.keys[strings.TrimSuffix(filepath.Base(path), ".pem")] = key
+	}
+	if len(q.keys) < required {
+		return fmt.Errorf("-quorum %d needs at least as many operator keys, found %d in %s", required, len(q.keys), keysDir)
+	}
+
+	batchQuorum = q
+	announceTo(q.add)
+	return nil
+}
+
+func (q *Quorum) add(change previewChange) {
+	q.mu.Lock()
+	q.changes = append(q.changes, change)
+	q.mu.Unlock()
+}
+
+func (q *Quorum) setOrganization(org string) {
+	if q != nil {
+		q.organization = org
+	}
+}
+
+func (q *Quorum) setAction(action string) {
+	if q != nil {
+		q.action = action
+	}
+}
+
+func openQuorumKey(path string) (ed25519.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: expected a PEM public key", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	ed, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected an Ed25519 public key", path)
+	}
+	return ed, nil
+}
+
+// Whether enough distinct operators approved confirming these changes of the action, logging what to sign if not
+func (q *Quorum) met(changes int) bool {
+	if q == nil {
+		return true
+	}
+	q.mu.Lock()
+	digest := quorumDigest(q.changes)
+	q.changes = nil
+	q.mu.Unlock()
+	if changes <= q.threshold {
+		return true
+	}
+
+	approvals, err := q.collect()
+	if err != nil {
+		slog.Error("Unable to collect approvals", "approvals", q.approvals, "error", err)
+		return false
+	}
+
+	approvedBy := map[string]bool{}
+	for _, a := range approvals {
+		if a.BatchID != q.batchID {
+			continue
+		}
+		key := q.keys[a.Operator]
+		if key == nil {
+			slog.Warn("ignoring approval, no key for operator", "operator", a.Operator)
+			continue
+		}
+		if a.Organization != q.organization || a.Action != q.action || a.Changes != changes || a.Digest != digest {
+			slog.Warn("ignoring approval, it's for other changes", "operator", a.Operator, "organization", a.Organization, "action", a.Action, "changes", a.Changes, "digest", a.Digest)
+			continue
+		}
+		if !time.Now().Before(a.ExpiresAt) {
+			slog.Warn("ignoring approval, expired", "operator", a.Operator, "expiresAt", a.ExpiresAt)
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(a.Signature))
+		if err != nil || !ed25519.Verify(key, []byte(quorumMessage(a)), signature) {
+			slog.Warn("ignoring approval, signature doesn't match", "operator", a.Operator)
+			continue
+		}
+		approvedBy[a.Operator] = true
+	}
+
+	var operators []string
+	for operator := range approvedBy {
+		operators = append(operators, operator)
+	}
+	sort.Strings(operators)
+
+	if len(operators) < q.required {
+		slog.Warn(fmt.Sprintf("Quorum not met, %d of %d approval(s)", len(operators), q.required), "batchID", q.batchID, "organization", q.organization, "action", q.action, "changes", changes, "digest", digest, "approvedBy", operators)
+		return false
+	}
+	slog.Info(fmt.Sprintf("Quorum met, %d of %d approval(s)", len(operators), q.required), "batchID", q.batchID, "approvedBy", operators)
+	return true
+}
+
+// The approval files in the directory, or those the approvals API returns
+func (q *Quorum) collect() ([]*QuorumApproval, error) {
+	if strings.HasPrefix(q.approvals, "http://") || strings.HasPrefix(q.approvals, "https://") {
+		return fetchApprovals(q.approvals, q.batchID)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(q.approvals, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var approvals []*QuorumApproval
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		a := &QuorumApproval{}
+		if err := json.Unmarshal(b, a); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		approvals = append(approvals, a)
+	}
+	return approvals, nil
+}
+
+// GET the batch's approvals from an approvals API, e.g. a change management webhook collecting them
+func fetchApprovals(approvalsURL, batchID string) ([]*QuorumApproval, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, approvalsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	query := req.URL.Query()
+	query.Set("batchID", batchID)
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("approvals API returned %s", resp.Status)
+	}
+
+	var approvals []*QuorumApproval
+	if err := json.NewDecoder(resp.Body).Decode(&approvals); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
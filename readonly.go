@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Refuses every request which could change anything, so -read-only invocations can only ever look
+type readOnlyTransport struct {
+	next http.RoundTripper
+}
+
+func (r *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("read-only, refusing %s %s", req.Method, req.URL.Path)
+	}
+	return r.next.RoundTrip(req)
+}
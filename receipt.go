@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// The Runs a batch canceled or discarded, and the commands which would start equivalent ones, to recover from an
+// over-aggressive sweep
+type Receipt struct {
+	BatchID      string        `json:"batchID"`
+	Organization string        `json:"organization"`
+	WrittenAt    time.Time     `json:"writtenAt"`
+	Runs         []*ReceiptRun `json:"runs"`
+	// Commands starting the Runs again, each from a workspace file written next to the receipt
+	Undo []string `json:"undo"`
+	// Runs the tool can't start again, which have to be queued by hand
+	Manual []string `json:"manual,omitempty"`
+}
+
+type ReceiptRun struct {
+	Workspace       string `json:"workspace"`
+	WorkspaceID     string `json:"workspaceID"`
+	RunID           string `json:"runID"`
+	Operation       string `json:"operation"`
+	PriorStatus     string `json:"priorStatus"`
+	Message         string `json:"message,omitempty"`
+	ConfigVersionID string `json:"configVersionID,omitempty"`
+	Commit          string `json:"commit,omitempty"`
+	IsDestroy       bool   `json:"isDestroy,omitempty"`
+	PlanOnly        bool   `json:"planOnly,omitempty"`
+}
+
+// Note a Run which was just canceled or discarded, with what it was created from
+func (c *Client) addReceipt(ctx context.Context, operation string, t target) {
+	r := &ReceiptRun{
+		Workspace:   t.ws.Name,
+		WorkspaceID: t.ws.ID,
+		RunID:       t.run.ID,
+		Operation:   operation,
+		PriorStatus: string(t.run.Status),
+		Message:     t.run.Message,
+		IsDestroy:   t.run.IsDestroy,
+		PlanOnly:    t.run.PlanOnly,
+	}
+	if cv := t.run.ConfigurationVersion; cv != nil {
+		r.ConfigVersionID = cv.ID
+		r.Commit = commitSHA(cv)
+		// The current Run is listed without its ingress attributes
+		if r.Commit == "" && cv.ID != "" {
+			read, err := c.ConfigurationVersions.ReadWithOptions(ctx, cv.ID, &tfe.ConfigurationVersionReadOptions{
+				Include: []tfe.ConfigVerIncludeOpt{tfe.ConfigVerIngressAttributes},
+			})
+			if err != nil {
+				slog.Debug("unable to read configuration version for receipt", "workspace", t.ws.Name, "configVersion", cv.ID, "error", err)
+			} else {
+				r.Commit = commitSHA(read)
+			}
+		}
+	}
+	c.receipt = append(c.receipt, r)
+}
+
+// Write the receipt, and a workspace file for each command in it, if any Runs were canceled or discarded
+func (c *Client) writeReceipt(path, org string) error {
+	if path == "" || len(c.receipt) == 0 {
+		return nil
+	}
+
+	receipt := &Receipt{BatchID: c.batchID, Organization: org, WrittenAt: time.Now().UTC(), Runs: c.receipt, Undo: []string{}}
+
+	// Runs from a commit are pinned to it, others start from the latest ingressed Configuration Version
+	var groups []string
+	workspaces := map[string][]string{}
+	for _, r := range c.receipt {
+		if r.IsDestroy || r.PlanOnly {
+			kind := "destroy"
+			if r.PlanOnly {
+				kind = "plan-only"
+			}
+			receipt.Manual = append(receipt.Manual, fmt.Sprintf("%s: %s run %s", r.Workspace, kind, r.RunID))
+			continue
+		}
+
+		group := "-reuse-config-version"
+		if r.Commit != "" {
+			group = "-commit-sha " + r.Commit
+		}
+		if _, ok := workspaces[group]; !ok {
+			groups = append(groups, group)
+		}
+		workspaces[group] = append(workspaces[group], fmt.Sprintf("%s %s %s", r.WorkspaceID, r.Workspace, r.RunID))
+	}
+
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for idx, group := range groups {
+		file := fmt.Sprintf("%s.%d.workspaces", base, idx+1)
+		content := fmt.Sprintf("# Workspaces whose Runs batch %s stopped, to start again with %s\n%s\n", c.batchID, group, strings.Join(workspaces[group], "\n"))
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			return err
+		}
+		receipt.Undo = append(receipt.Undo, fmt.Sprintf("go-tfe-bulk -org %s -workspace-file %s -action run %s", org, file, group))
+	}
+
+	b, err := json.MarshalIndent(receipt, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Wrote a receipt for %d stopped Run(s)", len(receipt.Runs)), "file", path, "undo", len(receipt.Undo), "manual", len(receipt.Manual))
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const REDACTED = "REDACTED"
+
+// Headers which carry credentials and are never written out
+var SECRET_HEADERS = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// One request and its response, a cassette is one per line
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+	Duration time.Duration    `json:"duration"`
+}
+
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Writes every request and response through it to a cassette, with credentials and sensitive values redacted
+type recorder struct {
+	next http.RoundTripper
+	file *os.File
+	mu   sync.Mutex
+}
+
+func newRecorder(path string, next http.RoundTripper) (*recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &recorder{next: next, file: f}, nil
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: redactHeaders(req.Header),
+			Body:    redactBody(reqBody),
+		},
+		Response: RecordedResponse{
+			Status:  resp.StatusCode,
+			Headers: redactHeaders(resp.Header),
+			Body:    redactBody(respBody),
+		},
+		Duration: time.Since(start),
+	}
+
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Read a request or response body, leaving it readable again
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	b, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = newBody(b)
+	return b, nil
+}
+
+func newBody(b []byte) io.ReadCloser {
+	if b == nil {
+		return http.NoBody
+	}
+	return io.NopCloser(bytes.NewReader(b))
+}
+
+func redactHeaders(h http.Header) http.Header {
+	redacted := h.Clone()
+	for _, key := range SECRET_HEADERS {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, REDACTED)
+		}
+	}
+	return redacted
+}
+
+// Replace tokens, and the values of sensitive Variables, anywhere in a JSON body
+func redactBody(b []byte) string {
+	var doc any
+	if len(b) == 0 || json.Unmarshal(b, &doc) != nil {
+		return string(b)
+	}
+
+	redacted, err := json.Marshal(redactValue(doc))
+	if err != nil {
+		return string(b)
+	}
+	return string(redacted)
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			switch {
+			case key == "token" && value != nil:
+				v[key] = REDACTED
+			case key == "value" && v["sensitive"] == true && value != nil:
+				v[key] = REDACTED
+			default:
+				v[key] = redactValue(value)
+			}
+		}
+	case []any:
+		for idx, value := range v {
+			v[idx] = redactValue(value)
+		}
+	}
+	return v
+}
+
+// Answers requests from a cassette instead of the API, in the order they were recorded
+type replayer struct {
+	responses map[string][]RecordedResponse
+	mu        sync.Mutex
+}
+
+func newReplayer(path string) (*replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &replayer{responses: map[string][]RecordedResponse{}}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		interaction := Interaction{}
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		u, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		key := replayKey(interaction.Request.Method, u)
+		r.responses[key] = append(r.responses[key], interaction.Response)
+	}
+
+	return r, scanner.Err()
+}
+
+// Requests are matched on method, path and query so the cassette can be replayed against any address
+func replayKey(method string, u *url.URL) string {
+	return fmt.Sprintf("%s %s", method, u.RequestURI())
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := replayKey(req.Method, req.URL)
+
+	r.mu.Lock()
+	responses := r.responses[key]
+	if len(responses) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s", key)
+	}
+	// Keep answering with the last response, e.g. for polling
+	recorded := responses[0]
+	if len(responses) > 1 {
+		r.responses[key] = responses[1:]
+	}
+	r.mu.Unlock()
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
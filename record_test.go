@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", ``, ``},
+		{"not JSON", `token=abc`, `token=abc`},
+		{"token", `{"data":{"attributes":{"token":"abc.atlasv1.xyz"}}}`, `{"data":{"attributes":{"token":"REDACTED"}}}`},
+		{"null token", `{"token":null}`, `{"token":null}`},
+		{
+			"sensitive variable",
+			`{"data":[{"attributes":{"key":"db_password","value":"hunter2","sensitive":true}}]}`,
+			`{"data":[{"attributes":{"key":"db_password","sensitive":true,"value":"REDACTED"}}]}`,
+		},
+		{
+			"plain variable",
+			`{"data":[{"attributes":{"key":"region","value":"eu-west-1","sensitive":false}}]}`,
+			`{"data":[{"attributes":{"key":"region","sensitive":false,"value":"eu-west-1"}}]}`,
+		},
+		{
+			"nested in included",
+			`{"included":[{"attributes":{"name":"t","token":"secret"}}]}`,
+			`{"included":[{"attributes":{"name":"t","token":"REDACTED"}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactBody([]byte(tt.body)); got != tt.want {
+				t.Errorf("redactBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc.atlasv1.xyz")
+	h.Set("Set-Cookie", "session=abc")
+	h.Set("Content-Type", "application/vnd.api+json")
+
+	redacted := redactHeaders(h)
+	for key, want := range map[string]string{"Authorization": REDACTED, "Set-Cookie": REDACTED, "Content-Type": "application/vnd.api+json"} {
+		if got := redacted.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if h.Get("Authorization") != "Bearer abc.atlasv1.xyz" {
+		t.Error("redactHeaders() changed the request's headers")
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		io.WriteString(w, `{"data":{"attributes":{"key":"db_password","value":"hunter2","sensitive":true}}}`)
+	}))
+	defer srv.Close()
+
+	cassette := filepath.Join(t.TempDir(), "cassette.ndjson")
+	rec, err := newRecorder(cassette, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v2/vars/var-1?include=x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer abc.atlasv1.xyz")
+	resp, err := rec.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(resp.Body); !strings.Contains(string(b), "hunter2") {
+		t.Errorf("recorder changed the response the caller reads: %s", b)
+	}
+	rec.file.Close()
+
+	b, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"abc.atlasv1.xyz", "hunter2"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains %q: %s", secret, b)
+		}
+	}
+
+	// Replayed against another address, matching only on the path and query
+	rep, err := newReplayer(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := http.NewRequest(http.MethodGet, "http://elsewhere/api/v2/vars/var-1?include=x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = rep.RoundTrip(replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/vnd.api+json" {
+		t.Errorf("replayed %d %v", resp.StatusCode, resp.Header)
+	}
+	if _, err := rep.RoundTrip(httptest.NewRequest(http.MethodGet, "http://elsewhere/api/v2/vars/var-2", nil)); err == nil {
+		t.Error("replayer answered a request which wasn't recorded")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Write to the named file, or stdout when no file is given
+func writeReport(path, report string) error {
+	if path == "" {
+		fmt.Print(report)
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(report), 0o644); err != nil {
+		return err
+	}
+	slog.Info("report written", "file", path)
+
+	return nil
+}
+
+// Write a value as indented JSON, or only the results of the -query if one was given
+func (c *Client) writeJSONReport(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if c.query == "" {
+		return writeReport(path, string(b)+"\n")
+	}
+
+	results, err := evalQuery(b, c.query)
+	if err != nil {
+		return err
+	}
+	report, err := formatQueryResults(results)
+	if err != nil {
+		return err
+	}
+
+	return writeReport(path, report)
+}
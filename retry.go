@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	conflictBackoff    = 2 * time.Second
+	conflictBackoffMax = 30 * time.Second
+)
+
+// Retries mutations which conflict (409), e.g. because the Workspace is locked or a Run is mid-transition,
+// waiting longer between each attempt
+type conflictRetry struct {
+	next    http.RoundTripper
+	retries int
+}
+
+func (r *conflictRetry) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Unlocking an unlocked Workspace conflicts too, but that won't resolve itself
+	if req.Method == http.MethodGet || strings.HasSuffix(req.URL.Path, "unlock") {
+		return r.next.RoundTrip(req)
+	}
+
+	getBody := req.GetBody
+	if getBody == nil {
+		// Without GetBody the body is read once, to send it again on every attempt
+		var body []byte
+		if req.Body != nil && req.Body != http.NoBody {
+			b, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			body = b
+		}
+		getBody = func() (io.ReadCloser, error) { return newBody(body), nil }
+	} else if req.Body != nil {
+		// Every attempt gets its own body from GetBody
+		req.Body.Close()
+	}
+
+	wait := conflictBackoff
+	for attempt := 0; ; attempt++ {
+		// Each attempt sends a clone with a fresh body, leaving the caller's request as it was
+		attemptReq := req.Clone(req.Context())
+		body, err := getBody()
+		if err != nil {
+			return nil, err
+		}
+		attemptReq.Body = body
+
+		resp, err := r.next.RoundTrip(attemptReq)
+		if err != nil || resp.StatusCode != http.StatusConflict || attempt >= r.retries {
+			return resp, err
+		}
+		resp.Body.Close()
+
+		slog.Warn("conflict, retrying", "method", req.Method, "path", req.URL.Path, "attempt", attempt+1, "wait", wait)
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		wait = min(wait*2, conflictBackoffMax)
+	}
+}
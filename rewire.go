@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// How long to wait for the rewired Workspace(s) to ingress from VCS over their new connection, and how often to check
+const (
+	rewireVerifyTimeout = 2 * time.Minute
+	rewireVerifyPoll    = 5 * time.Second
+)
+
+// A VCS-driven Workspace to rewire, and the Configuration Version it last ingressed before
+type rewireTarget struct {
+	ws        *tfe.Workspace
+	ingressID string
+}
+
+// Move the VCS-driven Workspace(s) connected through one OAuth token (ot-...) or GitHub App installation (ghain-...)
+// to another, e.g. after rotating a GitHub App installation, then check each ingresses over the new connection
+func (c *Client) RewireVCS(ctx context.Context, org, search string, assume bool, from, to string) error {
+	for _, id := range []string{from, to} {
+		if vcsConnectionKey(id) == "" {
+			return fmt.Errorf("expected -from-vcs and -to-vcs to be OAuth token (ot-) or GitHub App installation (ghain-) IDs, got %q", id)
+		}
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var rewireList []*rewireTarget
+	for _, ws := range workspaces {
+		if ws.VCSRepo == nil {
+			continue
+		}
+		connected, err := c.connectedThrough(ctx, ws, from)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if !connected {
+			continue
+		}
+		if !ws.Permissions.CanUpdate {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		cvs, err := c.getRecentConfigVersions(ctx, ws.ID)
+		if err != nil {
+			return err
+		}
+		t := &rewireTarget{ws: ws}
+		if latest := latestIngress(cvs); latest != nil {
+			t.ingressID = latest.ID
+		}
+
+		slog.Info("can rewire", "workspace", ws.Name, "repo", ws.VCSRepo.Identifier, "from", from, "to", to)
+		rewireList = append(rewireList, t)
+	}
+
+	slog.Info(fmt.Sprintf("Found %d of %d Workspace(s) connected through %s", len(rewireList), len(workspaces), from))
+
+	if !confirm(len(rewireList), assume) {
+		return nil
+	}
+
+	var rewired []*rewireTarget
+	for _, t := range rewireList {
+		if err := c.pauser.wait(ctx); err != nil {
+			return err
+		}
+
+		slog.Info("rewiring", "workspace", t.ws.Name, "to", to)
+		if err := c.updateRawAttributes(ctx, t.ws, map[string]any{"vcs-repo": rewiredVCSRepo(t.ws.VCSRepo, to)}); err != nil {
+			if err := c.tolerate(t.ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		c.acted(ctx, "rewire-vcs", t.ws, nil)
+		rewired = append(rewired, t)
+	}
+
+	// Changing the connection ingresses the latest commit, so the Workspaces are checked once they've all had the
+	// chance to
+	return c.verifyIngress(ctx, rewired)
+}
+
+// The vcs-repo attribute the connection ID goes in, or "" if it isn't one
+func vcsConnectionKey(id string) string {
+	switch {
+	case strings.HasPrefix(id, "ot-"):
+		return "oauth-token-id"
+	case strings.HasPrefix(id, "ghain-"):
+		return "github-app-installation-id"
+	}
+	return ""
+}
+
+// Whether the Workspace's repository is connected through the OAuth token or GitHub App installation; go-tfe
+// v1.10.0 doesn't model the latter
+func (c *Client) connectedThrough(ctx context.Context, ws *tfe.Workspace, id string) (bool, error) {
+	if vcsConnectionKey(id) == "oauth-token-id" {
+		return ws.VCSRepo.OAuthTokenID == id, nil
+	}
+
+	attrs, err := c.getRawAttributes(ctx, fmt.Sprintf("workspaces/%s", url.PathEscape(ws.ID)))
+	if err != nil {
+		return false, err
+	}
+	repo, _ := attrs["vcs-repo"].(map[string]any)
+	return repo["github-app-installation-id"] == id, nil
+}
+
+// The Workspace's repository settings, connected through the new ID instead
+func rewiredVCSRepo(repo *tfe.VCSRepo, to string) map[string]any {
+	rewired := map[string]any{
+		"identifier":                 repo.Identifier,
+		"branch":                     repo.Branch,
+		"ingress-submodules":         repo.IngressSubmodules,
+		"oauth-token-id":             nil,
+		"github-app-installation-id": nil,
+	}
+	if repo.TagsRegex != "" {
+		rewired["tags-regex"] = repo.TagsRegex
+	}
+	rewired[vcsConnectionKey(to)] = to
+	return rewired
+}
+
+// Wait for each rewired Workspace to ingress a new Configuration Version, warning about those which fail to or
+// haven't by the timeout
+func (c *Client) verifyIngress(ctx context.Context, rewired []*rewireTarget) error {
+	deadline := time.Now().Add(rewireVerifyTimeout)
+	verified := 0
+	for _, t := range rewired {
+		for {
+			cvs, err := c.getRecentConfigVersions(ctx, t.ws.ID)
+			if err != nil {
+				return err
+			}
+
+			latest := latestIngress(cvs)
+			if latest != nil && latest.ID != t.ingressID {
+				if latest.Status == tfe.ConfigurationErrored {
+					slog.Warn("ingress failing after rewire", "workspace", t.ws.Name, "error", latest.ErrorMessage)
+					break
+				}
+				if latest.Status == tfe.ConfigurationUploaded {
+					slog.Info("verified", "workspace", t.ws.Name, "configVersionID", latest.ID, "commit", commitSHA(latest))
+					verified++
+					break
+				}
+			}
+
+			if time.Now().After(deadline) {
+				slog.Warn("no ingress since rewire, check with -action branch-check", "workspace", t.ws.Name)
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(rewireVerifyPoll):
+			}
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Verified %d of %d rewired Workspace(s) ingress over the new connection", verified, len(rewired)))
+	return nil
+}
+
+// Change the branch the VCS-driven Workspace(s) tracking one branch track to another, e.g. master to main, optionally
+// queueing a plan-only Run on each afterwards to check the new branch plans cleanly
+func (c *Client) RetargetBranch(ctx context.Context, org, search string, assume bool, from, to string, verifyPlan bool) error {
+	if from == "" || to == "" {
+		return fmt.Errorf("-from-branch and -to-branch are required for retarget-branch")
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var retargetList []*tfe.Workspace
+	for _, ws := range workspaces {
+		if ws.VCSRepo == nil || ws.VCSRepo.Branch != from {
+			continue
+		}
+		if !ws.Permissions.CanUpdate || (verifyPlan && !ws.Permissions.CanQueueRun) {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		slog.Info("can retarget", "workspace", ws.Name, "repo", ws.VCSRepo.Identifier, "from", from, "to", to)
+		retargetList = append(retargetList, ws)
+	}
+
+	slog.Info(fmt.Sprintf("Found %d of %d Workspace(s) tracking branch %s", len(retargetList), len(workspaces), from))
+
+	if confirm(len(retargetList), assume) {
+		for _, ws := range retargetList {
+			if err := c.pauser.wait(ctx); err != nil {
+				return err
+			}
+
+			repo, err := c.retargetedVCSRepo(ctx, ws, to)
+			if err == nil {
+				slog.Info("retargeting", "workspace", ws.Name, "branch", to)
+				err = c.updateRawAttributes(ctx, ws, map[string]any{"vcs-repo": repo})
+			}
+			if err != nil {
+				if err := c.tolerate(ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			ws.VCSRepo.Branch = to
+
+			var run *tfe.Run
+			if verifyPlan {
+				if run, err = c.createVerificationRun(ctx, ws); err != nil {
+					return fmt.Errorf("%s: retargeted but unable to queue a verification plan: %w", ws.Name, err)
+				}
+				slog.Info("verification plan queued", "workspace", ws.Name, "runID", run.ID)
+			}
+			c.acted(ctx, "retarget-branch", ws, run)
+		}
+	}
+
+	return nil
+}
+
+// The Workspace's repository settings tracking the new branch, read raw since go-tfe v1.10.0 doesn't model GitHub
+// App installations
+func (c *Client) retargetedVCSRepo(ctx context.Context, ws *tfe.Workspace, branch string) (map[string]any, error) {
+	attrs, err := c.getRawAttributes(ctx, fmt.Sprintf("workspaces/%s", url.PathEscape(ws.ID)))
+	if err != nil {
+		return nil, err
+	}
+	current, _ := attrs["vcs-repo"].(map[string]any)
+
+	repo := map[string]any{"branch": branch}
+	for _, key := range []string{"identifier", "ingress-submodules", "oauth-token-id", "github-app-installation-id", "tags-regex"} {
+		if value, ok := current[key]; ok && value != nil && value != "" {
+			repo[key] = value
+		}
+	}
+	return repo, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// Local constraints on what the tool may do, every Workspace an action would touch is checked against them
+type Rules struct {
+	rules []*Rule
+}
+
+// Denies its actions on the Workspaces it matches, e.g. confirm on prod outside the change window
+type Rule struct {
+	Name string `json:"name" yaml:"name"`
+	// Actions the rule applies to, all of them if empty
+	Actions []string `json:"actions,omitempty" yaml:"actions"`
+	// Workspaces with any of these tags are matched, all of them if empty
+	Tags []string `json:"tags,omitempty" yaml:"tags"`
+	// Workspaces with any of these tags are never matched, e.g. destroy requires sandbox
+	UnlessTags []string `json:"unlessTags,omitempty" yaml:"unlessTags"`
+	// The rule only applies outside this window, if set
+	Window *ChangeWindow `json:"window,omitempty" yaml:"window"`
+}
+
+// Days and hours, e.g. {"days": ["tue", "thu"], "start": "09:00", "end": "17:00", "timezone": "Europe/London"}
+type ChangeWindow struct {
+	Days     []string `json:"days,omitempty" yaml:"days"`
+	Start    string   `json:"start" yaml:"start"`
+	End      string   `json:"end" yaml:"end"`
+	Timezone string   `json:"timezone,omitempty" yaml:"timezone"`
+
+	location   *time.Location
+	start, end time.Duration
+}
+
+// Load a JSON list of Rules, or YAML with a .yaml or .yml extension
+func openRules(path string) (*Rules, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Rules{}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &r.rules)
+	default:
+		err = json.Unmarshal(b, &r.rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for idx, rule := range r.rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", idx)
+		}
+		if rule.Window != nil {
+			if err := rule.Window.parse(); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, rule.Name, err)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func (w *ChangeWindow) parse() error {
+	var err error
+	if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+		return err
+	}
+	if w.start, err = parseClock(w.Start); err != nil {
+		return fmt.Errorf("window start: %w", err)
+	}
+	if w.end, err = parseClock(w.End); err != nil {
+		return fmt.Errorf("window end: %w", err)
+	}
+	for idx, day := range w.Days {
+		w.Days[idx] = strings.ToLower(day)
+		if !slices.Contains(WEEKDAYS, w.Days[idx]) {
+			return fmt.Errorf("window has unknown day %q, expected one of %s", day, strings.Join(WEEKDAYS, ", "))
+		}
+	}
+	return nil
+}
+
+// Indexed by time.Weekday
+var WEEKDAYS = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Offset from midnight of "HH:MM"
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Whether the time falls in the window, which may wrap past midnight
+func (w *ChangeWindow) contains(t time.Time) bool {
+	t = t.In(w.location)
+	if len(w.Days) > 0 && !slices.Contains(w.Days, WEEKDAYS[t.Weekday()]) {
+		return false
+	}
+
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if w.start <= w.end {
+		return clock >= w.start && clock < w.end
+	}
+	return clock >= w.start || clock < w.end
+}
+
+// The name of the first Rule denying the action on the Workspace, or "" if it's allowed; nil Rules allow everything
+func (r *Rules) denies(action string, ws *tfe.Workspace, now time.Time) string {
+	if r == nil {
+		return ""
+	}
+
+	for _, rule := range r.rules {
+		if len(rule.Actions) > 0 && !slices.Contains(rule.Actions, action) {
+			continue
+		}
+		if len(rule.Tags) > 0 && !hasAnyTag(ws, rule.Tags) {
+			continue
+		}
+		if hasAnyTag(ws, rule.UnlessTags) {
+			continue
+		}
+		if rule.Window != nil && rule.Window.contains(now) {
+			continue
+		}
+		return rule.Name
+	}
+
+	return ""
+}
+
+// The rule denying the action on a Workspace known only by its ID, e.g. from a state file, read for its tags; nothing
+// is read without -rules
+func (c *Client) deniedByRule(ctx context.Context, workspaceID string) (string, error) {
+	if c.rules == nil {
+		return "", nil
+	}
+	ws, err := c.Workspaces.ReadByID(ctx, workspaceID)
+	if err != nil {
+		return "", err
+	}
+	return c.rules.denies(c.action, ws, time.Now()), nil
+}
+
+func hasAnyTag(ws *tfe.Workspace, tags []string) bool {
+	for _, tag := range tags {
+		if slices.Contains(ws.TagNames, tag) {
+			return true
+		}
+	}
+	return false
+}
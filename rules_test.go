@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+const testRules = `
+- name: prod confirms only in the change window
+  actions: [confirm]
+  tags: [prod]
+  window: {days: [tue, thu], start: "09:00", end: "16:00", timezone: UTC}
+- name: var-import only on sandboxes
+  actions: [var-import]
+  unlessTags: [sandbox]
+- name: no overnight discards
+  actions: [discard]
+  window: {start: "06:00", end: "22:00", timezone: UTC}
+`
+
+func TestRulesDenies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(testRules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := openRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod := &tfe.Workspace{Name: "prod", TagNames: []string{"prod"}}
+	sandbox := &tfe.Workspace{Name: "sandbox", TagNames: []string{"sandbox"}}
+	// A Tuesday
+	inWindow := time.Date(2026, 10, 13, 10, 0, 0, 0, time.UTC)
+	afterWindow := time.Date(2026, 10, 13, 17, 0, 0, 0, time.UTC)
+	monday := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	midnight := time.Date(2026, 10, 13, 0, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		rules  *Rules
+		action string
+		ws     *tfe.Workspace
+		now    time.Time
+		want   string
+	}{
+		{"no rules", nil, "confirm", prod, afterWindow, ""},
+		{"in the window", rules, "confirm", prod, inWindow, ""},
+		{"after the window", rules, "confirm", prod, afterWindow, "prod confirms only in the change window"},
+		{"outside the window's days", rules, "confirm", prod, monday, "prod confirms only in the change window"},
+		{"untagged", rules, "confirm", sandbox, afterWindow, ""},
+		{"other action", rules, "cancel", prod, afterWindow, ""},
+		{"unless tagged", rules, "var-import", sandbox, inWindow, ""},
+		{"not unless tagged", rules, "var-import", prod, inWindow, "var-import only on sandboxes"},
+		{"every workspace in the window", rules, "discard", sandbox, inWindow, ""},
+		{"every workspace outside the window", rules, "discard", sandbox, midnight, "no overnight discards"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.denies(tt.action, tt.ws, tt.now); got != tt.want {
+				t.Errorf("denies() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeWindowWrapsPastMidnight(t *testing.T) {
+	w := &ChangeWindow{Start: "22:00", End: "06:00", Timezone: "UTC"}
+	if err := w.parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	for hour, want := range map[int]bool{21: false, 22: true, 23: true, 0: true, 5: true, 6: false, 12: false} {
+		if got := w.contains(time.Date(2026, 10, 13, hour, 0, 0, 0, time.UTC)); got != want {
+			t.Errorf("contains(%02d:00) = %v, want %v", hour, got, want)
+		}
+	}
+}
+
+func TestOpenRulesRefusesUnknownDays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	rules := `[{"name": "typo", "window": {"days": ["tues"], "start": "09:00", "end": "17:00"}}]`
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openRules(path); err == nil {
+		t.Error("openRules() accepted an unknown day")
+	}
+}
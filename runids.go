@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Actions which can be given -run-ids instead of searching for Workspaces
+var RUN_ID_ACTIONS = []string{"confirm", "discard", "cancel", "comment", "apply-logs", "echo"}
+
+// Run IDs separated by commas or whitespace, or read from @FILE, e.g. the output of a -query on a report
+func parseRunIDs(s string) ([]string, error) {
+	if path, ok := strings.CutPrefix(s, "@"); ok {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s = string(b)
+	}
+
+	var runIDs []string
+	for _, id := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '"' || r == ' ' || r == '\n' || r == '\t' }) {
+		if !strings.HasPrefix(id, "run-") {
+			return nil, fmt.Errorf("invalid run ID %q", id)
+		}
+		runIDs = append(runIDs, id)
+	}
+	if len(runIDs) == 0 {
+		return nil, fmt.Errorf("no run IDs given")
+	}
+	return runIDs, nil
+}
+
+// The Workspace of every -run-ids Run, with that Run standing in for its CurrentRun
+func (c *Client) listRunWorkspaces(ctx context.Context) ([]*tfe.Workspace, error) {
+	var workspaces []*tfe.Workspace
+	for _, runID := range c.runIDs {
+		ws, err := c.getRunWorkspace(ctx, runID)
+		if err != nil {
+			if err := c.tolerate(runID, err); err != nil {
+				return workspaces, err
+			}
+			continue
+		}
+		workspaces = append(workspaces, ws)
+	}
+
+	c.selection = workspaces
+	return workspaces, nil
+}
+
+// The Run's Workspace, with the Run as its CurrentRun
+func (c *Client) getRunWorkspace(ctx context.Context, runID string) (*tfe.Workspace, error) {
+	run, err := c.Runs.ReadWithOptions(ctx, runID, &tfe.RunReadOptions{
+		Include: []tfe.RunIncludeOpt{tfe.RunWorkspace},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", runID, err)
+	}
+	if run.Workspace == nil {
+		return nil, fmt.Errorf("%s: no workspace", runID)
+	}
+
+	ws := *run.Workspace
+	if ws.CurrentRun != nil && ws.CurrentRun.ID != runID {
+		slog.Info("not the current run", "workspace", ws.Name, "runID", runID, "currentRunID", ws.CurrentRun.ID)
+	}
+	ws.CurrentRun = run
+	return &ws, nil
+}
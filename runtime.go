@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// The exit code once -max-runtime is reached, so CI can tell a batch which ran out of time from one which failed
+const EXIT_MAX_RUNTIME = 3
+
+// How long operations in flight at the -max-runtime deadline get to finish before they're cut off
+const maxRuntimeGrace = time.Minute
+
+// Stop starting operations at the deadline, and cut off those still in flight after the grace period
+func (c *Client) limitRuntime(ctx context.Context, maxRuntime time.Duration) (context.Context, context.CancelFunc) {
+	c.pauser.deadline = time.Now().Add(maxRuntime)
+	return context.WithDeadline(ctx, c.pauser.deadline.Add(maxRuntimeGrace))
+}
+
+func maxRuntimeReached(err error) bool {
+	return errors.Is(err, ErrMaxRuntime) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Write the selected Workspace(s) no action has changed yet in the select action's JSON, to carry on with
+// -workspace-file; apply resumes from its -checkpoint instead
+func (c *Client) writeRemaining(path string) error {
+	remaining := []SelectedWorkspace{}
+	for _, ws := range c.selection {
+		if !c.changed[ws.ID] {
+			remaining = append(remaining, SelectedWorkspace{ID: ws.ID, Name: ws.Name})
+		}
+	}
+
+	b, err := json.MarshalIndent(remaining, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	slog.Warn(fmt.Sprintf("Max runtime reached, %d of %d Workspace(s) remaining", len(remaining), len(c.selection)), "file", path)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// A Workspace selected by the select action
+type SelectedWorkspace struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Report the Workspace(s) the search, rules and ledger select, without acting on them, as "ID NAME" lines or JSON.
+// Either can be edited or filtered by other scripts, then given back with -workspace-file
+func (c *Client) Select(ctx context.Context, org, search, format, reportFile string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported -format %q for select, expected text or json", format)
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	selected := []SelectedWorkspace{}
+	for _, ws := range workspaces {
+		selected = append(selected, SelectedWorkspace{ID: ws.ID, Name: ws.Name})
+	}
+
+	if format == "json" {
+		return c.writeJSONReport(reportFile, selected)
+	}
+
+	var b strings.Builder
+	for _, ws := range selected {
+		fmt.Fprintf(&b, "%s\t%s\n", ws.ID, ws.Name)
+	}
+	return writeReport(reportFile, b.String())
+}
+
+// Workspace names or IDs from a file: the select action's JSON, whose IDs are used, a JSON list of strings, or one
+// per line with anything after the first field, and lines starting with #, ignored
+func openWorkspaceFile(path string) (map[string]bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	if trimmed := strings.TrimSpace(string(b)); strings.HasPrefix(trimmed, "[") {
+		var selected []SelectedWorkspace
+		if err := json.Unmarshal(b, &selected); err == nil {
+			for _, ws := range selected {
+				refs = append(refs, ws.ID)
+			}
+		} else if err := json.Unmarshal(b, &refs); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		for _, line := range strings.Split(trimmed, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			refs = append(refs, fields[0])
+		}
+	}
+
+	wanted := map[string]bool{}
+	for _, ref := range refs {
+		if ref != "" {
+			wanted[ref] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return nil, fmt.Errorf("%s: no workspaces given", path)
+	}
+	return wanted, nil
+}
+
+// Only the listed Workspace(s) named or identified in the -workspace-file, warning about those it names which
+// weren't listed
+func (c *Client) restrictWorkspaces(listed []*tfe.Workspace) []*tfe.Workspace {
+	found := map[string]bool{}
+	var workspaces []*tfe.Workspace
+	for _, ws := range listed {
+		if c.workspaces[ws.ID] || c.workspaces[ws.Name] {
+			found[ws.ID] = true
+			found[ws.Name] = true
+			workspaces = append(workspaces, ws)
+		}
+	}
+
+	for ref := range c.workspaces {
+		if !found[ref] {
+			slog.Warn("skipping, not found by the search", "workspace", ref)
+		}
+	}
+	return workspaces
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Variables whose values are secrets by convention, matched case-insensitively against their keys
+const DEFAULT_SENSITIVE_PATTERNS = "*_SECRET,*_TOKEN,*_PASSWORD,*_API_KEY,*_PRIVATE_KEY"
+
+// The Variables which look like secrets but aren't marked sensitive, so the API returns their values to anyone
+// who can read the Workspace
+type SensitiveAuditReport struct {
+	Organization string   `json:"organization"`
+	Search       string   `json:"search,omitempty"`
+	Patterns     []string `json:"patterns"`
+	Workspaces   int      `json:"workspaces"`
+	// Workspaces whose Variables couldn't be read, skipped over with -skip-errors
+	Unread  int                `json:"unread"`
+	Exposed []*ExposedVariable `json:"exposed"`
+}
+
+type ExposedVariable struct {
+	Workspace string `json:"workspace"`
+	Key       string `json:"key"`
+	Category  string `json:"category"`
+	Pattern   string `json:"pattern"`
+}
+
+type exposedFix struct {
+	ws *tfe.Workspace
+	v  *tfe.Variable
+}
+
+func parseSensitivePatterns(s string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(s, ",") {
+		pattern = strings.ToUpper(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("-sensitive-patterns: invalid pattern %q", pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("-sensitive-patterns is required for sensitive-audit")
+	}
+	return patterns, nil
+}
+
+// The pattern the key matches, or ""
+func sensitivePattern(key string, patterns []string) string {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, strings.ToUpper(key)); ok {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// Report the Variables of the Workspace(s) whose keys match the patterns but which aren't sensitive, and with fix,
+// rewrite them as sensitive
+func (c *Client) SensitiveAudit(ctx context.Context, org, search string, assume bool, patternList string, fix bool, reportFile string) error {
+	patterns, err := parseSensitivePatterns(patternList)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	report := &SensitiveAuditReport{Organization: org, Search: search, Patterns: patterns, Workspaces: len(workspaces), Exposed: []*ExposedVariable{}}
+	var fixList []exposedFix
+	for _, ws := range workspaces {
+		variables, err := c.getVariables(ctx, ws.ID)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			report.Unread++
+			continue
+		}
+
+		canFix, exposed := ws.Permissions.CanUpdateVariable, false
+		for _, v := range variables {
+			pattern := sensitivePattern(v.Key, patterns)
+			if v.Sensitive || pattern == "" {
+				continue
+			}
+
+			slog.Warn("variable not sensitive", "workspace", ws.Name, "key", v.Key, "category", v.Category, "pattern", pattern)
+			report.Exposed = append(report.Exposed, &ExposedVariable{Workspace: ws.Name, Key: v.Key, Category: string(v.Category), Pattern: pattern})
+			exposed = true
+			if fix && canFix {
+				fixList = append(fixList, exposedFix{ws, v})
+			}
+		}
+		if fix && exposed && !canFix {
+			c.missingPermission("workspace", ws.Name)
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Found %d Variable(s) which should be sensitive across %d Workspace(s)", len(report.Exposed), len(workspaces)), "unread", report.Unread)
+	if err := c.writeJSONReport(reportFile, report); err != nil {
+		return err
+	}
+
+	if !fix {
+		return nil
+	}
+
+	if confirm(len(fixList), assume) {
+		for _, f := range fixList {
+			if err := c.pauser.wait(ctx); err != nil {
+				return err
+			}
+
+			// Marking a Variable sensitive can't be undone, and its value is rewritten with it so it's stored as a
+			// sensitive one from then on
+			slog.Info("marking sensitive", "workspace", f.ws.Name, "key", f.v.Key)
+			_, err := c.Variables.Update(ctx, f.ws.ID, f.v.ID, tfe.VariableUpdateOptions{
+				Value:     tfe.String(f.v.Value),
+				Sensitive: tfe.Bool(true),
+			})
+			if err != nil {
+				if err := c.tolerate(f.ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			c.acted(ctx, "mark-sensitive", f.ws, nil)
+		}
+	}
+
+	return nil
+}
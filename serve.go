@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Flags a slash command may give, as key=value
+var SERVE_ARGS = []string{"org", "search", "from-batch", "sort", "reverse"}
+
+// Flags of the server which aren't passed on to the commands it runs, since they're about serving or each command
+// decides them
+var SERVE_ONLY_FLAGS = []string{"action", "listen", "serve-actions", "serve-users", "assume-yes"}
+
+const (
+	// Slack requests signed longer ago than this are refused, so a captured one can't be replayed
+	slackSignatureMaxAge = 5 * time.Minute
+	// How long after its dry run a command can be confirmed
+	serveDryRunTTL = 15 * time.Minute
+	// How much of a command's output is posted back, from the end
+	serveOutputLimit = 3000
+)
+
+// Runs slash commands as subprocesses of the tool, a dry run with -read-only first, and only the same command
+// confirmed after it
+type commandServer struct {
+	secret []byte
+	// The only Organization commands can act on, if -org was given
+	org     string
+	actions []string
+	// The Slack user IDs allowed to run commands
+	users []string
+	// The server's own flags, e.g. -config, -read-only and -rules, which every command is run with
+	flags []string
+	// One command at a time, batches shouldn't overlap
+	running sync.Mutex
+
+	mu sync.Mutex
+	// When each command's dry run succeeded
+	dryRuns map[string]time.Time
+}
+
+// A parsed slash command, e.g. "cleanup org=prod search=payments confirm"
+type serveCommand struct {
+	action string
+	// As key=value, org first
+	args    []string
+	confirm bool
+}
+
+// The command without confirm, identifying its dry run
+func (cmd *serveCommand) key() string {
+	return strings.Join(append([]string{cmd.action}, cmd.args...), " ")
+}
+
+// Listen for Slack slash commands, authenticated with the app's signing secret in SLACK_SIGNING_SECRET and from
+// the allowed users, running the allowed actions and posting their output back to the channel
+func Serve(ctx context.Context, listen, org string, actions, users []string) error {
+	secret := os.Getenv("SLACK_SIGNING_SECRET")
+	if secret == "" {
+		return errors.New("SLACK_SIGNING_SECRET is required for serve")
+	}
+	var allowed []string
+	for _, user := range users {
+		if user = strings.TrimSpace(user); user != "" {
+			allowed = append(allowed, user)
+		}
+	}
+	if len(allowed) == 0 {
+		return errors.New("-serve-users is required for serve, anyone in the Slack workspace could run commands otherwise")
+	}
+	for _, action := range actions {
+		if !slices.Contains(ACTIONS, action) || slices.Contains(SINGLE_ACTIONS, action) || action == "serve" {
+			return fmt.Errorf("-serve-actions: %q can't be run from a slash command", action)
+		}
+	}
+
+	s := &commandServer{secret: []byte(secret), org: org, actions: actions, users: allowed, flags: serverFlags(), dryRuns: map[string]time.Time{}}
+	server := &http.Server{Addr: listen, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	slog.Info("serving slash commands", "listen", listen, "actions", actions, "users", allowed, "flags", s.flags)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *commandServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "expected POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.verify(r.Header, body, time.Now()) {
+		slog.Warn("refusing unsigned slash command", "remote", r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// user_name can be changed by its user, user_id can't
+	userID, user, responseURL := form.Get("user_id"), form.Get("user_name"), form.Get("response_url")
+	if !strings.HasPrefix(responseURL, "https://hooks.slack.com/") {
+		http.Error(w, "expected a Slack response_url", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(s.users, userID) {
+		slog.Warn("refusing slash command from user not allowed", "userID", userID, "user", user)
+		respondSlack(w, "You're not allowed to run commands, ask to be added to -serve-users")
+		return
+	}
+
+	cmd, err := s.parse(form.Get("text"))
+	if err != nil {
+		respondSlack(w, err.Error())
+		return
+	}
+	if cmd.confirm && !s.dryRunSucceeded(cmd) {
+		respondSlack(w, fmt.Sprintf("Run `%s` first, a command can only be confirmed within %s of its dry run", cmd.key(), serveDryRunTTL))
+		return
+	}
+	if !s.running.TryLock() {
+		respondSlack(w, "Another command is running, try again once it's finished")
+		return
+	}
+
+	slog.Info("running slash command", "userID", userID, "user", user, "command", cmd.key(), "confirm", cmd.confirm)
+	go func() {
+		defer s.running.Unlock()
+		s.run(cmd, user, responseURL)
+	}()
+
+	if cmd.confirm {
+		respondSlack(w, fmt.Sprintf("Running `%s`...", cmd.key()))
+	} else {
+		respondSlack(w, fmt.Sprintf("Dry run of `%s`, confirm with `%s confirm`...", cmd.key(), cmd.key()))
+	}
+}
+
+// The flags the server was given, other than SERVE_ONLY_FLAGS and those commands give themselves, as -name=value
+func serverFlags() []string {
+	var flags []string
+	flag.Visit(func(f *flag.Flag) {
+		if slices.Contains(SERVE_ONLY_FLAGS, f.Name) || slices.Contains(SERVE_ARGS, f.Name) {
+			return
+		}
+		flags = append(flags, fmt.Sprintf("-%s=%s", f.Name, f.Value.String()))
+	})
+	return flags
+}
+
+// Whether the request was signed with the signing secret recently, see
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func (s *commandServer) verify(h http.Header, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(h.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > slackSignatureMaxAge || age < -slackSignatureMaxAge {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "v0:%d:%s", ts, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(h.Get("X-Slack-Signature")))
+}
+
+func (s *commandServer) parse(text string) (*serveCommand, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !slices.Contains(s.actions, fields[0]) {
+		return nil, fmt.Errorf("usage: <%s> [key=value ...] [confirm], with keys %s", strings.Join(s.actions, "|"), strings.Join(SERVE_ARGS, ", "))
+	}
+
+	cmd := &serveCommand{action: fields[0]}
+	fields = fields[1:]
+	if len(fields) > 0 && fields[len(fields)-1] == "confirm" {
+		cmd.confirm = true
+		fields = fields[:len(fields)-1]
+	}
+
+	org := ""
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || !slices.Contains(SERVE_ARGS, key) {
+			return nil, fmt.Errorf("expected key=value with keys %s, got %q", strings.Join(SERVE_ARGS, ", "), field)
+		}
+		if key == "org" {
+			org = value
+			continue
+		}
+		cmd.args = append(cmd.args, field)
+	}
+
+	switch {
+	case org == "" && s.org == "":
+		return nil, errors.New("org= is required")
+	case org == "":
+		org = s.org
+	case s.org != "" && org != s.org:
+		return nil, fmt.Errorf("only org=%s can be acted on", s.org)
+	}
+	cmd.args = append([]string{"org=" + org}, cmd.args...)
+	return cmd, nil
+}
+
+func (s *commandServer) dryRunSucceeded(cmd *serveCommand) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at, ok := s.dryRuns[cmd.key()]
+	return ok && time.Since(at) < serveDryRunTTL
+}
+
+// Run the command as a subprocess of the tool, without a terminal so the confirmation prompt declines a dry run,
+// and post the end of its output back
+func (s *commandServer) run(cmd *serveCommand, user, responseURL string) {
+	args := append([]string{"-action", cmd.action}, s.flags...)
+	for _, arg := range cmd.args {
+		args = append(args, "-"+arg)
+	}
+	if cmd.confirm {
+		args = append(args, "-assume-yes")
+	} else {
+		args = append(args, "-read-only")
+	}
+
+	exe, err := os.Executable()
+	output := []byte{}
+	if err == nil {
+		output, err = exec.Command(exe, args...).CombinedOutput()
+	}
+
+	s.mu.Lock()
+	if cmd.confirm {
+		delete(s.dryRuns, cmd.key())
+	} else if err == nil {
+		s.dryRuns[cmd.key()] = time.Now()
+	}
+	s.mu.Unlock()
+
+	result := "finished"
+	if err != nil {
+		result = fmt.Sprintf("failed (%s)", err)
+		slog.Warn("slash command failed", "user", user, "command", cmd.key(), "error", err)
+	}
+	if len(output) > serveOutputLimit {
+		output = append([]byte("...\n"), output[len(output)-serveOutputLimit:]...)
+	}
+	kind := "Dry run"
+	if cmd.confirm {
+		kind = "Run"
+	}
+
+	text := fmt.Sprintf("%s of `%s` by %s %s:\n```\n%s\n```", kind, cmd.key(), user, result, bytes.TrimSpace(output))
+	if err := postSlackResponse(responseURL, text); err != nil {
+		slog.Warn("unable to post slash command result", "command", cmd.key(), "error", err)
+	}
+}
+
+// An immediate reply only the user sees
+func respondSlack(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"response_type": "ephemeral", "text": text})
+}
+
+// A delayed reply to the channel, so others see what was run
+func postSlackResponse(responseURL, text string) error {
+	body, err := json.Marshal(map[string]string{"response_type": "in_channel", "text": text})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("response_url returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+const (
+	settingBool = "bool"
+	// e.g. "14d" or "36h", or empty to clear
+	settingDuration = "duration"
+)
+
+// Workspace settings -settings can change and their kind, by API attribute; many are newer than go-tfe so they're
+// read and written raw
+var WORKSPACE_SETTINGS = map[string]string{
+	"allow-destroy-plan":             settingBool,
+	"assessments-enabled":            settingBool,
+	"auto-apply":                     settingBool,
+	"auto-apply-run-trigger":         settingBool,
+	"auto-destroy-activity-duration": settingDuration,
+	"queue-all-runs":                 settingBool,
+	"speculative-enabled":            settingBool,
+}
+
+var settingDurationPattern = regexp.MustCompile(`^[0-9]+[dh]$`)
+
+// Attribute values from "key=value,key=value"; a duration without a value clears it
+func parseSettings(s string) (map[string]any, error) {
+	settings := map[string]any{}
+	if s == "" {
+		return settings, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		switch WORKSPACE_SETTINGS[key] {
+		case settingBool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("setting %s: expected true or false, got %q", key, value)
+			}
+			settings[key] = b
+		case settingDuration:
+			if value == "" {
+				settings[key] = nil
+				continue
+			}
+			if !settingDurationPattern.MatchString(value) {
+				return nil, fmt.Errorf("setting %s: expected a number of days or hours like 14d or 36h, got %q", key, value)
+			}
+			settings[key] = value
+		default:
+			return nil, fmt.Errorf("unknown setting %q, expected one of %s", key, strings.Join(settingNames(), ", "))
+		}
+	}
+	return settings, nil
+}
+
+func settingNames() []string {
+	var names []string
+	for name := range WORKSPACE_SETTINGS {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Payload for a raw Workspace update with only the changed attributes, so null can clear one
+type settingsUpdate struct {
+	Data settingsUpdateData `json:"data"`
+}
+
+type settingsUpdateData struct {
+	Type       string         `json:"type"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// Change the settings on the Workspace(s) where they differ, skipping those the platform doesn't have
+func (c *Client) Settings(ctx context.Context, org, search string, assume bool, settings map[string]any) error {
+	if len(settings) == 0 {
+		return fmt.Errorf("-settings is required for settings")
+	}
+	return c.changeAttributes(ctx, org, search, assume, "settings", settings)
+}
+
+// Turn auto-apply on or off for the Runs run triggers queue on the Workspace(s), leaving auto-apply for the rest
+// as it is
+func (c *Client) TriggerAutoApply(ctx context.Context, org, search string, assume bool, value string) error {
+	if value == "" {
+		return fmt.Errorf("-trigger-auto-apply is required for auto-apply-run-trigger")
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("-trigger-auto-apply: expected true or false, got %q", value)
+	}
+	return c.changeAttributes(ctx, org, search, assume, "auto-apply-run-trigger", map[string]any{"auto-apply-run-trigger": enabled})
+}
+
+func (c *Client) changeAttributes(ctx context.Context, org, search string, assume bool, op string, settings map[string]any) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	type settingsChange struct {
+		ws      *tfe.Workspace
+		changed map[string]any
+	}
+	var changeList []settingsChange
+	unsupported := map[string]bool{}
+	for _, ws := range workspaces {
+		if !ws.Permissions.CanUpdate {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		current, err := c.getRawAttributes(ctx, fmt.Sprintf("workspaces/%s", url.PathEscape(ws.ID)))
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		changed := map[string]any{}
+		for key, value := range settings {
+			was, ok := current[key]
+			if !ok {
+				if !unsupported[key] {
+					slog.Warn(fmt.Sprintf("skipping %s, not supported by %s", key, c.platform.Name))
+					unsupported[key] = true
+				}
+				continue
+			}
+			if sameAttribute(was, value) {
+				continue
+			}
+			slog.Info("can change", "workspace", ws.Name, "setting", key, "from", was, "to", value)
+			changed[key] = value
+		}
+
+		if len(changed) > 0 {
+			changeList = append(changeList, settingsChange{ws, changed})
+		}
+	}
+
+	if confirm(len(changeList), assume) {
+		for _, change := range changeList {
+			if err := c.pauser.wait(ctx); err != nil {
+				return err
+			}
+
+			slog.Info("updating", "workspace", change.ws.Name)
+			if err := c.updateRawAttributes(ctx, change.ws, change.changed); err != nil {
+				if err := c.tolerate(change.ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			c.acted(ctx, op, change.ws, nil)
+		}
+	}
+
+	return nil
+}
+
+// Timestamps are compared as times, since the API may format them differently than they were given
+func sameAttribute(was, value any) bool {
+	if was == value {
+		return true
+	}
+	wasStr, ok1 := was.(string)
+	valueStr, ok2 := value.(string)
+	if !ok1 || !ok2 {
+		return false
+	}
+	wasTime, err1 := time.Parse(time.RFC3339, wasStr)
+	valueTime, err2 := time.Parse(time.RFC3339, valueStr)
+	return err1 == nil && err2 == nil && wasTime.Equal(valueTime)
+}
+
+func (c *Client) updateRawAttributes(ctx context.Context, ws *tfe.Workspace, attributes map[string]any) error {
+	req, err := c.NewRequest("PATCH", fmt.Sprintf("workspaces/%s", url.PathEscape(ws.ID)), &settingsUpdate{
+		Data: settingsUpdateData{Type: "workspaces", Attributes: attributes},
+	})
+	if err != nil {
+		return err
+	}
+	return req.Do(ctx, nil)
+}
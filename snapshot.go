@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+)
+
+// Point-in-time state of the fleet
+type Snapshot struct {
+	Organization string                        `json:"organization"`
+	Search       string                        `json:"search,omitempty"`
+	TakenAt      time.Time                     `json:"takenAt"`
+	Workspaces   map[string]*WorkspaceSnapshot `json:"workspaces"`
+}
+
+// The settings and current Run of a single Workspace
+type WorkspaceSnapshot struct {
+	ID               string   `json:"id"`
+	AutoApply        bool     `json:"autoApply"`
+	ExecutionMode    string   `json:"executionMode"`
+	AgentPoolID      string   `json:"agentPoolID,omitempty"`
+	TerraformVersion string   `json:"terraformVersion"`
+	WorkingDirectory string   `json:"workingDirectory,omitempty"`
+	VCSRepo          string   `json:"vcsRepo,omitempty"`
+	VCSBranch        string   `json:"vcsBranch,omitempty"`
+	Locked           bool     `json:"locked"`
+	ResourceCount    int      `json:"resourceCount"`
+	Tags             []string `json:"tags"`
+	CurrentRunID     string   `json:"currentRunID"`
+	CurrentRunStatus string   `json:"currentRunStatus"`
+}
+
+// Serialize the state of the Workspace(s) to JSON
+func (c *Client) Snapshot(ctx context.Context, org, search, reportFile string) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &Snapshot{
+		Organization: org,
+		Search:       search,
+		TakenAt:      time.Now().UTC(),
+		Workspaces:   make(map[string]*WorkspaceSnapshot, len(workspaces)),
+	}
+
+	for _, ws := range workspaces {
+		wss := &WorkspaceSnapshot{
+			ID:               ws.ID,
+			AutoApply:        ws.AutoApply,
+			ExecutionMode:    ws.ExecutionMode,
+			AgentPoolID:      ws.AgentPoolID,
+			TerraformVersion: ws.TerraformVersion,
+			WorkingDirectory: ws.WorkingDirectory,
+			Locked:           ws.Locked,
+			ResourceCount:    ws.ResourceCount,
+			Tags:             append([]string{}, ws.TagNames...),
+		}
+		if ws.CurrentRun != nil {
+			wss.CurrentRunID = ws.CurrentRun.ID
+			wss.CurrentRunStatus = string(ws.CurrentRun.Status)
+		}
+		if ws.VCSRepo != nil {
+			wss.VCSRepo = ws.VCSRepo.Identifier
+			wss.VCSBranch = ws.VCSRepo.Branch
+		}
+		sort.Strings(wss.Tags)
+
+		snapshot.Workspaces[ws.Name] = wss
+	}
+
+	return c.writeJSONReport(reportFile, snapshot)
+}
+
+// Compare two snapshot files, printing added and removed Workspaces and changed fields
+func DiffSnapshots(fromFile, toFile, reportFile string) error {
+	from, err := readSnapshot(fromFile)
+	if err != nil {
+		return err
+	}
+	to, err := readSnapshot(toFile)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s (%s)\n+++ %s (%s)\n", fromFile, from.TakenAt.Format(time.RFC3339), toFile, to.TakenAt.Format(time.RFC3339))
+
+	names := maps.Keys(from.Workspaces)
+	for name := range to.Workspaces {
+		if _, ok := from.Workspaces[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := 0
+	for _, name := range names {
+		before, inFrom := from.Workspaces[name]
+		after, inTo := to.Workspaces[name]
+
+		switch {
+		case !inTo:
+			fmt.Fprintf(&b, "- %s\n", name)
+			changes++
+		case !inFrom:
+			fmt.Fprintf(&b, "+ %s\n", name)
+			changes++
+		default:
+			fields, err := diffFields(before, after)
+			if err != nil {
+				return err
+			}
+			for _, field := range fields {
+				fmt.Fprintf(&b, "~ %s: %s\n", name, field)
+				changes++
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%d change(s)\n", changes)
+
+	return writeReport(reportFile, b.String())
+}
+
+func readSnapshot(path string) (*Snapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(b, snapshot); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return snapshot, nil
+}
+
+// Describe every JSON field which differs between the two values
+func diffFields(before, after any) ([]string, error) {
+	a, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := maps.Keys(a)
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var fields []string
+	for _, k := range keys {
+		if string(a[k]) != string(b[k]) {
+			fields = append(fields, fmt.Sprintf("%s %s -> %s", k, orNull(a[k]), orNull(b[k])))
+		}
+	}
+
+	return fields, nil
+}
+
+func toFields(v any) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	return fields, json.Unmarshal(b, &fields)
+}
+
+func orNull(raw json.RawMessage) string {
+	if raw == nil {
+		return "null"
+	}
+	return string(raw)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// What triggered a Run, as reported by run-sources
+const (
+	TriggerVCS        = "vcs"
+	TriggerAPI        = "api"
+	TriggerUI         = "ui"
+	TriggerCLI        = "cli"
+	TriggerRunTrigger = "run-trigger"
+	// Auto-destroy and other Runs the platform queues itself
+	TriggerLifecycle = "lifecycle"
+	// This tool, which queues through the API
+	TriggerBulk  = "go-tfe-bulk"
+	TriggerOther = "other"
+)
+
+// How many of the current and recent Runs each trigger source accounts for, overall and by Workspace
+type RunSourceReport struct {
+	Organization string                 `json:"organization"`
+	Search       string                 `json:"search,omitempty"`
+	From         time.Time              `json:"from"`
+	To           time.Time              `json:"to"`
+	Runs         int                    `json:"runs"`
+	Sources      map[string]int         `json:"sources"`
+	Workspaces   []*WorkspaceRunSources `json:"workspaces"`
+}
+
+type WorkspaceRunSources struct {
+	Workspace string         `json:"workspace"`
+	Runs      int            `json:"runs"`
+	Sources   map[string]int `json:"sources"`
+}
+
+// Break the Workspace(s)' Runs created within since, and their current Runs, down by what triggered them, busiest
+// Workspaces first, to show what's driving load on the run queue
+func (c *Client) RunSources(ctx context.Context, org, search string, since time.Duration, reportFile string) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	report := &RunSourceReport{
+		Organization: org,
+		Search:       search,
+		From:         time.Now().Add(-since),
+		To:           time.Now(),
+		Sources:      map[string]int{},
+		Workspaces:   []*WorkspaceRunSources{},
+	}
+	for _, ws := range workspaces {
+		runs, err := c.getRunsSince(ctx, ws.ID, report.From)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if ws.CurrentRun != nil && ws.CurrentRun.CreatedAt.Before(report.From) {
+			runs = append(runs, ws.CurrentRun)
+		}
+
+		wrs := &WorkspaceRunSources{Workspace: ws.Name, Runs: len(runs), Sources: map[string]int{}}
+		for _, run := range runs {
+			source := runSource(ws, run)
+			wrs.Sources[source]++
+			report.Sources[source]++
+		}
+		report.Runs += len(runs)
+		report.Workspaces = append(report.Workspaces, wrs)
+	}
+
+	sort.SliceStable(report.Workspaces, func(i, j int) bool { return report.Workspaces[i].Runs > report.Workspaces[j].Runs })
+
+	slog.Info(fmt.Sprintf("Found %d Run(s) across %d Workspace(s)", report.Runs, len(workspaces)))
+	return c.writeJSONReport(reportFile, report)
+}
+
+// What triggered the Run. Runs queued when a Configuration Version is uploaded come from VCS on VCS-driven
+// Workspaces, and otherwise from an API upload
+func runSource(ws *tfe.Workspace, run *tfe.Run) string {
+	switch source := string(run.Source); {
+	case isOwnRun(run):
+		return TriggerBulk
+	case run.Source == tfe.RunSourceConfigurationVersion && ws.VCSRepo != nil:
+		return TriggerVCS
+	case run.Source == tfe.RunSourceConfigurationVersion, run.Source == tfe.RunSourceAPI:
+		return TriggerAPI
+	case run.Source == tfe.RunSourceUI:
+		return TriggerUI
+	case source == "tfe-run-trigger":
+		return TriggerRunTrigger
+	case source == "tfe-infrastructure-lifecycle":
+		return TriggerLifecycle
+	case strings.HasPrefix(source, "terraform"):
+		return TriggerCLI
+	}
+	return TriggerOther
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// The "key:value" tags every Workspace must have, so tag-driven filters can rely on them
+type Taxonomy struct {
+	// Keys every Workspace must have a tag for
+	Required []string `json:"required"`
+	// The values allowed for a key, any if it isn't listed
+	Values map[string][]string `json:"values,omitempty"`
+}
+
+// Where a Workspace falls short of the taxonomy
+type TaxonomyViolation struct {
+	Workspace string   `json:"workspace"`
+	Missing   []string `json:"missing,omitempty"`
+	// Tags whose value isn't allowed, as "key:value"
+	Invalid []string `json:"invalid,omitempty"`
+	// Tags to add from the -tag-lookup file, as "key:value"
+	Adding []string `json:"adding,omitempty"`
+}
+
+type TaxonomyReport struct {
+	Organization string               `json:"organization"`
+	Search       string               `json:"search,omitempty"`
+	Workspaces   int                  `json:"workspaces"`
+	Violations   []*TaxonomyViolation `json:"violations"`
+}
+
+func openTaxonomy(path string) (*Taxonomy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Taxonomy{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(t.Required) == 0 && len(t.Values) == 0 {
+		return nil, fmt.Errorf("%s: expected required keys or allowed values", path)
+	}
+	return t, nil
+}
+
+// Tags to add by Workspace name, e.g. {"billing-prod": {"owner": "payments"}}
+func openTagLookup(path string) (map[string]map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := map[string]map[string]string{}
+	if err := json.Unmarshal(b, &lookup); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return lookup, nil
+}
+
+// Report the Workspace(s) missing a required tag or with a value the taxonomy doesn't allow. With a lookup file,
+// the missing tags it has a value for are then added
+func (c *Client) TagAudit(ctx context.Context, org, search string, assume bool, taxonomyFile, lookupFile, reportFile string) error {
+	if taxonomyFile == "" {
+		return fmt.Errorf("-taxonomy is required for tag-audit")
+	}
+	taxonomy, err := openTaxonomy(taxonomyFile)
+	if err != nil {
+		return err
+	}
+	var lookup map[string]map[string]string
+	if lookupFile != "" {
+		if lookup, err = openTagLookup(lookupFile); err != nil {
+			return err
+		}
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	report := &TaxonomyReport{Organization: org, Search: search, Workspaces: len(workspaces), Violations: []*TaxonomyViolation{}}
+	var fixList []*tfe.Workspace
+	adding := map[string][]string{}
+	for _, ws := range workspaces {
+		v := taxonomy.check(ws)
+		if v == nil {
+			continue
+		}
+
+		for _, key := range v.Missing {
+			value, ok := lookup[ws.Name][key]
+			if !ok {
+				continue
+			}
+			if allowed, listed := taxonomy.Values[key]; listed && !slices.Contains(allowed, value) {
+				slog.Warn("skipping lookup, value not allowed", "workspace", ws.Name, "key", key, "value", value)
+				continue
+			}
+			v.Adding = append(v.Adding, key+":"+value)
+		}
+
+		slog.Warn("tags violate taxonomy", "workspace", ws.Name, "missing", v.Missing, "invalid", v.Invalid, "adding", v.Adding)
+		report.Violations = append(report.Violations, v)
+
+		if len(v.Adding) > 0 {
+			if !ws.Permissions.CanUpdate {
+				c.missingPermission("workspace", ws.Name)
+				continue
+			}
+			fixList = append(fixList, ws)
+			adding[ws.ID] = v.Adding
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Found %d of %d Workspace(s) violating the taxonomy", len(report.Violations), len(workspaces)))
+	if err := c.writeJSONReport(reportFile, report); err != nil {
+		return err
+	}
+
+	if lookup == nil {
+		return nil
+	}
+
+	if confirm(len(fixList), assume) {
+		for _, ws := range fixList {
+			if err := c.pauser.wait(ctx); err != nil {
+				return err
+			}
+
+			var tags []*tfe.Tag
+			for _, tag := range adding[ws.ID] {
+				tags = append(tags, &tfe.Tag{Name: tag})
+			}
+			slog.Info("tagging", "workspace", ws.Name, "tags", adding[ws.ID])
+			if err := c.Workspaces.AddTags(ctx, ws.ID, tfe.WorkspaceAddTagsOptions{Tags: tags}); err != nil {
+				if err := c.tolerate(ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			c.acted(ctx, "tag", ws, nil)
+		}
+	}
+
+	return nil
+}
+
+// Where the Workspace's tags fall short, nil if they don't
+func (t *Taxonomy) check(ws *tfe.Workspace) *TaxonomyViolation {
+	values := map[string][]string{}
+	for _, tag := range ws.TagNames {
+		if k, v, ok := strings.Cut(tag, ":"); ok {
+			values[k] = append(values[k], v)
+		}
+	}
+
+	v := &TaxonomyViolation{Workspace: ws.Name}
+	for _, key := range t.Required {
+		if len(values[key]) == 0 {
+			v.Missing = append(v.Missing, key)
+		}
+	}
+	for key, allowed := range t.Values {
+		for _, value := range values[key] {
+			if !slices.Contains(allowed, value) {
+				v.Invalid = append(v.Invalid, key+":"+value)
+			}
+		}
+	}
+	sort.Strings(v.Invalid)
+
+	if len(v.Missing) == 0 && len(v.Invalid) == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// The Organization's teams, their members, and which of the Workspace(s) each can modify, for access reviews
+type TeamAuditReport struct {
+	Organization string       `json:"organization"`
+	Search       string       `json:"search,omitempty"`
+	GeneratedAt  time.Time    `json:"generatedAt"`
+	Teams        []*TeamAudit `json:"teams"`
+}
+
+type TeamAudit struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+	SSOTeamID  string `json:"ssoTeamID,omitempty"`
+	// Can manage every Workspace in the Organization, whatever its team access
+	ManageWorkspaces bool                   `json:"manageWorkspaces"`
+	Members          []*TeamMember          `json:"members"`
+	Modifies         []*TeamWorkspaceAccess `json:"modifies"`
+}
+
+type TeamMember struct {
+	Username       string `json:"username"`
+	Email          string `json:"email,omitempty"`
+	ServiceAccount bool   `json:"serviceAccount,omitempty"`
+	TwoFactor      bool   `json:"twoFactor"`
+}
+
+type TeamWorkspaceAccess struct {
+	Workspace string `json:"workspace"`
+	Access    string `json:"access"`
+}
+
+// Report every team of the Organization with its members, and the Workspace(s) its access lets it modify: start
+// or apply Runs, or change Variables or state
+func (c *Client) TeamAudit(ctx context.Context, org, search, reportFile string) error {
+	teams, err := c.getTeams(ctx, org, tfe.TeamUsers)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	report := &TeamAuditReport{Organization: org, Search: search, GeneratedAt: time.Now().UTC(), Teams: []*TeamAudit{}}
+	byID := map[string]*TeamAudit{}
+	for _, team := range teams {
+		ta := &TeamAudit{
+			ID:         team.ID,
+			Name:       team.Name,
+			Visibility: team.Visibility,
+			SSOTeamID:  team.SSOTeamID,
+			Members:    []*TeamMember{},
+			Modifies:   []*TeamWorkspaceAccess{},
+		}
+		if team.OrganizationAccess != nil {
+			ta.ManageWorkspaces = team.OrganizationAccess.ManageWorkspaces
+		}
+		for _, u := range team.Users {
+			ta.Members = append(ta.Members, &TeamMember{
+				Username:       u.Username,
+				Email:          u.Email,
+				ServiceAccount: u.IsServiceAccount,
+				TwoFactor:      u.TwoFactor != nil && u.TwoFactor.Enabled,
+			})
+		}
+		sort.Slice(ta.Members, func(i, j int) bool { return ta.Members[i].Username < ta.Members[j].Username })
+
+		report.Teams = append(report.Teams, ta)
+		byID[team.ID] = ta
+	}
+
+	for _, ws := range workspaces {
+		access, err := c.getTeamAccess(ctx, ws.ID)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, a := range access {
+			ta := byID[a.Team.ID]
+			if ta == nil || !canModify(a) {
+				continue
+			}
+			ta.Modifies = append(ta.Modifies, &TeamWorkspaceAccess{Workspace: ws.Name, Access: string(a.Access)})
+		}
+	}
+
+	for _, ta := range report.Teams {
+		sort.Slice(ta.Modifies, func(i, j int) bool { return ta.Modifies[i].Workspace < ta.Modifies[j].Workspace })
+	}
+	sort.Slice(report.Teams, func(i, j int) bool { return report.Teams[i].Name < report.Teams[j].Name })
+
+	slog.Info(fmt.Sprintf("Found %d team(s) with access to %d Workspace(s)", len(teams), len(workspaces)))
+	return c.writeJSONReport(reportFile, report)
+}
+
+// Whether the access lets the team change the Workspace's infrastructure, not just read it
+func canModify(a *tfe.TeamAccess) bool {
+	switch a.Access {
+	case tfe.AccessAdmin, tfe.AccessWrite:
+		return true
+	case tfe.AccessCustom:
+		return a.Runs == tfe.RunsPermissionApply || a.Variables == tfe.VariablesPermissionWrite || a.StateVersions == tfe.StateVersionsPermissionWrite
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+// Lines read from stdin by a single goroutine, so the prompts and the pause key never race to read the same line
+type terminalInput struct {
+	once  sync.Once
+	lines chan string
+
+	mu sync.Mutex
+	// Prompts waiting for a line
+	prompting int
+	// Given every line typed while nothing prompts for one, if set
+	idle func(line string)
+}
+
+var terminal = &terminalInput{}
+
+// Whether stdin is an interactive terminal rather than a pipe or a file
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func (t *terminalInput) start() {
+	t.once.Do(func() {
+		t.lines = make(chan string)
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				t.mu.Lock()
+				idle := t.idle
+				if t.prompting > 0 {
+					idle = nil
+				}
+				t.mu.Unlock()
+
+				if idle != nil {
+					idle(scanner.Text())
+					continue
+				}
+				// Without a key listener, lines typed ahead wait for the next prompt
+				t.lines <- scanner.Text()
+			}
+			close(t.lines)
+		}()
+	})
+}
+
+// Read the line typed in answer to a prompt, without its newline
+func readLine() (string, error) {
+	terminal.start()
+
+	terminal.mu.Lock()
+	terminal.prompting++
+	terminal.mu.Unlock()
+	defer func() {
+		terminal.mu.Lock()
+		terminal.prompting--
+		terminal.mu.Unlock()
+	}()
+
+	line, ok := <-terminal.lines
+	if !ok {
+		return "", io.EOF
+	}
+	return line, nil
+}
+
+// Give every line typed between prompts to idle
+func (t *terminalInput) listen(idle func(line string)) {
+	t.mu.Lock()
+	t.idle = idle
+	t.mu.Unlock()
+	t.start()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+const throttlePollInterval = 10 * time.Second
+
+// Keeps bulk operations under the organization's run concurrency, leaving room for interactive users
+type Throttle struct {
+	org      string
+	limit    int
+	headroom int
+}
+
+// Use the given limit, or detect it from the organization's subscription
+func (c *Client) newThrottle(ctx context.Context, org string, limit, headroom int) (*Throttle, error) {
+	if limit == 0 {
+		attrs, err := c.getRawAttributes(ctx, fmt.Sprintf("organizations/%s/subscription", url.PathEscape(org)))
+		if err != nil {
+			return nil, fmt.Errorf("unable to detect run concurrency, set -max-concurrency: %w", err)
+		}
+		// JSON numbers decode as float64
+		ceiling, _ := attrs["runs-ceiling"].(float64)
+		if ceiling < 1 {
+			return nil, fmt.Errorf("unable to detect run concurrency, set -max-concurrency")
+		}
+		limit = int(ceiling)
+	}
+
+	slog.Info("throttling", "concurrency", limit, "headroom", headroom)
+	return &Throttle{org: org, limit: limit, headroom: headroom}, nil
+}
+
+// Block until the organization's run queue has room for one more Run; a nil Throttle never blocks
+func (c *Client) throttle(ctx context.Context) error {
+	t := c.throttler
+	if t == nil {
+		return nil
+	}
+
+	for {
+		capacity, err := c.Organizations.ReadCapacity(ctx, t.org)
+		if err != nil {
+			return err
+		}
+
+		if capacity.Running+capacity.Pending < max(t.limit-t.headroom, 1) {
+			return nil
+		}
+
+		slog.Info("waiting for run capacity", "running", capacity.Running, "pending", capacity.Pending, "concurrency", t.limit)
+		if err := sleep(ctx, throttlePollInterval); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+var TIMELINE_CSV_HEADER = []string{
+	"workspace_id", "workspace", "run_id", "status", "created_at", "plan_queued_at", "planning_at", "planned_at",
+	"cost_estimated_at", "policy_checked_at", "confirmed_at", "apply_queued_at", "applying_at", "applied_at",
+	"queued_seconds", "planning_seconds", "awaiting_confirmation_seconds", "applying_seconds",
+}
+
+// Export when each of the Workspace(s)' current Run reached each stage as CSV, with the time spent queued, planning,
+// waiting for confirmation and applying; stages a Run hasn't reached are empty
+func (c *Client) ExportTimeline(ctx context.Context, org, search, reportFile string) error {
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.Write(TIMELINE_CSV_HEADER); err != nil {
+		return err
+	}
+
+	exported := 0
+	for _, ws := range workspaces {
+		run := ws.CurrentRun
+		if run.StatusTimestamps == nil {
+			if run, err = c.Runs.Read(ctx, run.ID); err != nil {
+				if err := c.tolerate(ws.Name, err); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		ts := run.StatusTimestamps
+		if ts == nil {
+			ts = &tfe.RunStatusTimestamps{}
+		}
+		planned := ts.PlannedAt
+		if planned.IsZero() {
+			planned = ts.PlannedAndFinishedAt
+		}
+
+		record := []string{
+			ws.ID, ws.Name, run.ID, string(run.Status), timestamp(run.CreatedAt), timestamp(ts.PlanQueuedAt),
+			timestamp(ts.PlanningAt), timestamp(planned), timestamp(ts.CostEstimatedAt), timestamp(ts.PolicyCheckedAt),
+			timestamp(ts.ConfirmedAt), timestamp(ts.ApplyQueuedAt), timestamp(ts.ApplyingAt), timestamp(ts.AppliedAt),
+			seconds(run.CreatedAt, ts.PlanningAt), seconds(ts.PlanningAt, planned),
+			seconds(planned, ts.ConfirmedAt), seconds(ts.ApplyingAt, ts.AppliedAt),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		exported++
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	slog.Info(fmt.Sprintf("Exported the timeline of %d Run(s) for %d Workspace(s)", exported, len(workspaces)))
+	return writeReport(reportFile, b.String())
+}
+
+// RFC 3339, or empty if the stage wasn't reached
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Whole seconds from one stage to the next, or empty if either wasn't reached
+func seconds(from, to time.Time) string {
+	if from.IsZero() || to.IsZero() {
+		return ""
+	}
+	return strconv.Itoa(int(to.Sub(from).Seconds()))
+}
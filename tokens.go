@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// Retries requests which were refused with the other tokens: 401 means the token is invalid or expired, so
+// the next one is used from then on; 403 and 404 may just mean it's scoped to other Workspaces
+type tokenFallback struct {
+	next   http.RoundTripper
+	tokens []string
+	mu     sync.Mutex
+	// Index of the token currently in use
+	current int
+}
+
+func (t *tokenFallback) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	current := t.current
+	t.mu.Unlock()
+
+	var resp *http.Response
+	for attempt := 0; attempt < len(t.tokens); attempt++ {
+		idx := (current + attempt) % len(t.tokens)
+
+		// Don't modify the caller's request
+		try := req.Clone(req.Context())
+		try.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.tokens[idx]))
+		try.Body = newBody(body)
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = t.next.RoundTrip(try)
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			t.mu.Lock()
+			if t.current == idx && len(t.tokens) > 1 {
+				t.current = (idx + 1) % len(t.tokens)
+				slog.Warn("token refused, falling back to the next one", "token", idx+1, "next", t.current+1)
+			}
+			t.mu.Unlock()
+		case http.StatusForbidden, http.StatusNotFound:
+		default:
+			if attempt > 0 {
+				slog.Debug("request allowed with a fallback token", "path", req.URL.Path, "token", idx+1)
+			}
+			return resp, nil
+		}
+	}
+
+	return resp, nil
+}
+
+// Index of the token in use, which the last request succeeded with unless every token was refused
+func (t *tokenFallback) active() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	TokenUser         = "user"
+	TokenTeam         = "team"
+	TokenOrganization = "organization"
+	// Any other service account, e.g. an audit trail token
+	TokenServiceAccount = "service-account"
+)
+
+var TOKEN_TYPES = []string{TokenUser, TokenTeam, TokenOrganization, TokenServiceAccount}
+
+// Actions an organization token can't do: they can't start Runs or upload Configuration Versions
+var ORGANIZATION_TOKEN_UNSUPPORTED = []string{"run", "apply", "replan", "supersede", "migrate-remote"}
+
+// Team and organization tokens belong to one Organization, and may not be allowed to list the others
+func scopedToken(tokenType string) bool {
+	return tokenType == TokenTeam || tokenType == TokenOrganization
+}
+
+// What kind of token is in use, from -token-type or otherwise from who it authenticates as; "" if that can't be
+// read
+func (c *Client) getTokenType(ctx context.Context) string {
+	if c.tokenType == "" {
+		user, err := c.Users.ReadCurrent(ctx)
+		if err != nil {
+			slog.Debug("unable to tell the token type", "error", err)
+			return ""
+		}
+		c.tokenType = tokenType(user)
+	}
+	return c.tokenType
+}
+
+// The only Organization the token can list, for when -org isn't given
+func (c *Client) discoverOrganization(ctx context.Context) (string, error) {
+	orgs, err := c.getOrganizations(ctx)
+	if err != nil {
+		if scopedToken(c.getTokenType(ctx)) {
+			return "", fmt.Errorf("unable to discover the organization, %s tokens can't list organizations so -org is required: %w", c.tokenType, err)
+		}
+		return "", fmt.Errorf("unable to discover the organization, give -org: %w", err)
+	}
+
+	switch len(orgs) {
+	case 0:
+		return "", errors.New("unable to discover the organization, the token can't see any so -org is required")
+	case 1:
+		slog.Info("discovered organization", "org", orgs[0].Name)
+		return orgs[0].Name, nil
+	}
+	var names []string
+	for _, o := range orgs {
+		names = append(names, o.Name)
+	}
+	return "", fmt.Errorf("the token can see %d organizations, give one with -org: %s", len(orgs), strings.Join(names, ", "))
+}
+
+// Explain a refused request by what the token is allowed to do, since the API only says it was unauthorized or
+// not found
+func (c *Client) explainTokenError(ctx context.Context, action string, err error) error {
+	if !errors.Is(err, tfe.ErrUnauthorized) && !errors.Is(err, tfe.ErrResourceNotFound) {
+		return err
+	}
+
+	switch c.getTokenType(ctx) {
+	case TokenOrganization:
+		if slices.Contains(ORGANIZATION_TOKEN_UNSUPPORTED, action) {
+			return fmt.Errorf("%w: organization tokens can't start runs, use a team or user token for %s", err, action)
+		}
+	case TokenTeam:
+		return fmt.Errorf("%w: team tokens only reach the workspaces and settings the team has access to, check the team's access with -action whoami", err)
+	case TokenServiceAccount:
+		return fmt.Errorf("%w: the token is a service account which isn't a team or organization token, so may only reach what it was made for; use a team or user token for %s", err, action)
+	}
+	return err
+}
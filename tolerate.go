@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Kinds of error -skip-errors can skip Workspaces over
+const (
+	ErrorPermission = "permission"
+	ErrorNotFound   = "not-found"
+)
+
+var SKIPPABLE_ERRORS = []string{ErrorPermission, ErrorNotFound}
+
+// Which kind of skippable error it is, or "" if it isn't one
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, tfe.ErrResourceNotFound):
+		return ErrorNotFound
+	case errors.Is(err, tfe.ErrUnauthorized), strings.Contains(strings.ToLower(err.Error()), "forbidden"):
+		return ErrorPermission
+	}
+	return ""
+}
+
+// Swallow an error acting on a Workspace if -skip-errors allows its kind, counting it for the summary
+func (c *Client) tolerate(workspace string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	kind := errorKind(err)
+	if kind == "" || !c.skipErrors[kind] {
+		return err
+	}
+
+	slog.Debug("skipping, error", "workspace", workspace, "kind", kind, "error", err)
+	c.skipped[kind]++
+	return nil
+}
+
+// Warn that the token can't act on a Workspace, or just count it with -skip-errors permission; each is only
+// warned about once
+func (c *Client) missingPermission(args ...any) {
+	if !c.permissions.report(c.action, args) {
+		slog.Debug("missing permission, already reported", args...)
+		return
+	}
+	if c.skipErrors[ErrorPermission] {
+		c.skipped[ErrorPermission]++
+		return
+	}
+	slog.Warn("missing permission", args...)
+}
+
+// Summarise the Workspace(s) skipped over because of -skip-errors
+func (c *Client) reportSkipped() {
+	if len(c.skipped) == 0 {
+		return
+	}
+
+	kinds := make([]string, 0, len(c.skipped))
+	total := 0
+	for kind, count := range c.skipped {
+		kinds = append(kinds, fmt.Sprintf("%s=%d", kind, count))
+		total += count
+	}
+	sort.Strings(kinds)
+
+	slog.Info(fmt.Sprintf("Skipped %d Workspace(s) with errors", total), "kinds", strings.Join(kinds, ","))
+}
+
+// List the Workspace(s) matching -org and -search which no action changed, and why, so nothing important is
+// silently left out of a batch
+func (c *Client) reportUntouched() {
+	untouched := 0
+	for _, ws := range c.selection {
+		if c.changed[ws.ID] {
+			continue
+		}
+		untouched++
+		slog.Info("untouched", "workspace", ws.Name, "lastDecision", explanation.last(ws.Name))
+	}
+	slog.Info(fmt.Sprintf("%d of %d Workspace(s) untouched", untouched, len(c.selection)))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	tfe "github.com/hashicorp/go-tfe"
+)
+
+// Preflight the token, API connectivity, organization access and entitlements before wiring into automation
+func (c *Client) Validate(ctx context.Context, org, search string, cfg *Config) error {
+	failures := 0
+	fail := func(check string, err error) {
+		slog.Error("failed", "check", check, "error", err)
+		failures++
+	}
+
+	if cfg.path == "" {
+		slog.Info("ok", "check", "config", "file", "none")
+	} else {
+		slog.Info("ok", "check", "config", "file", cfg.path, "tokens", len(cfg.Tokens))
+	}
+	candidates := cfg.tokenCandidates(tfeAddress(), org)
+	for idx, candidate := range candidates {
+		slog.Info("token candidate", "order", idx+1, "source", candidate.source)
+	}
+
+	// Creating the Client already pinged the API
+	slog.Info("ok", "check", "connectivity", "address", tfeAddress(), "apiVersion", c.RemoteAPIVersion())
+
+	if user, err := c.Users.ReadCurrent(ctx); err != nil {
+		fail("token", err)
+	} else {
+		source := "replayed"
+		if active := c.fallback.active(); active < len(candidates) {
+			source = candidates[active].source
+		}
+		slog.Info("ok", "check", "token", "source", source, "username", user.Username, "serviceAccount", user.IsServiceAccount)
+	}
+
+	if o, err := c.Organizations.Read(ctx, org); err != nil {
+		fail("organization", err)
+	} else {
+		slog.Info("ok", "check", "organization", "name", o.Name, "email", o.Email)
+	}
+
+	if ent, err := c.Organizations.ReadEntitlements(ctx, org); err != nil {
+		fail("entitlements", err)
+	} else {
+		slog.Info("ok", "check", "entitlements",
+			"operations", ent.Operations,
+			"agents", ent.Agents,
+			"costEstimation", ent.CostEstimation,
+			"sentinel", ent.Sentinel,
+			"runTasks", ent.RunTasks,
+			"teams", ent.Teams,
+			"auditLogging", ent.AuditLogging,
+		)
+	}
+
+	opts := &tfe.WorkspaceListOptions{
+		ListOptions: tfe.ListOptions{
+			PageSize: 1,
+		},
+		Search: search,
+	}
+	if wsList, err := c.Workspaces.List(ctx, org, opts); err != nil {
+		fail("workspaces", err)
+	} else {
+		slog.Info("ok", "check", "workspaces", "search", search, "matching", wsList.TotalCount)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d check(s) failed", failures)
+	}
+	slog.Info("All checks passed")
+
+	return nil
+}
+
+// The address go-tfe resolves from the environment when none is configured
+func tfeAddress() string {
+	if address := os.Getenv("TFE_ADDRESS"); address != "" {
+		return address
+	}
+	if host := os.Getenv("TFE_HOSTNAME"); host != "" {
+		return fmt.Sprintf("https://%s", host)
+	}
+	return tfe.DefaultAddress
+}
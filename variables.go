@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/template"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// A Variable to set on every matching Workspace, the Value is a Go template over VariableContext
+type VariableSpec struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Category    string `json:"category,omitempty"`
+	HCL         bool   `json:"hcl,omitempty"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+}
+
+// The Workspace attributes available to Variable value templates
+type VariableContext struct {
+	ID           string
+	Name         string
+	Organization string
+	// Empty where projects aren't supported
+	Project string
+	Tags    []string
+	// Tags of the form "key:value", e.g. {{ .Tag.env }}
+	Tag map[string]string
+}
+
+// A Variable to create or update on a Workspace
+type variableChange struct {
+	ws       *tfe.Workspace
+	spec     VariableSpec
+	existing *tfe.Variable
+}
+
+// Set a single Variable on the Workspace(s)
+func (c *Client) VarSet(ctx context.Context, org, search string, assume bool, spec VariableSpec) error {
+	return c.setVariables(ctx, org, search, assume, []VariableSpec{spec})
+}
+
+// Set every Variable from a JSON file of VariableSpecs on the Workspace(s)
+func (c *Client) VarImport(ctx context.Context, org, search string, assume bool, varFile string) error {
+	b, err := os.ReadFile(varFile)
+	if err != nil {
+		return err
+	}
+
+	var specs []VariableSpec
+	if err := json.Unmarshal(b, &specs); err != nil {
+		return fmt.Errorf("%s: %w", varFile, err)
+	}
+
+	return c.setVariables(ctx, org, search, assume, specs)
+}
+
+func (c *Client) setVariables(ctx context.Context, org, search string, assume bool, specs []VariableSpec) error {
+	if err := normalizeVariableSpecs(specs); err != nil {
+		return err
+	}
+
+	templates := make([]*template.Template, len(specs))
+	for idx, spec := range specs {
+		tmpl, err := template.New(spec.Key).Option("missingkey=error").Parse(spec.Value)
+		if err != nil {
+			return fmt.Errorf("variable %s has an invalid value template: %w", spec.Key, err)
+		}
+		templates[idx] = tmpl
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	// Listing every Project's Workspaces is only worth it if a template uses them
+	var projects map[string]string
+	if c.supports(FeatureProjects) && slices.IndexFunc(specs, func(spec VariableSpec) bool { return strings.Contains(spec.Value, ".Project") }) >= 0 {
+		if projects, err = c.getProjectNames(ctx, org); err != nil {
+			return err
+		}
+	}
+
+	var changes []variableChange
+	for _, ws := range workspaces {
+		if !ws.Permissions.CanUpdateVariable {
+			c.missingPermission("workspace", ws.Name)
+			continue
+		}
+
+		existing, err := c.getVariables(ctx, ws.ID)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		vc := newVariableContext(ws, projects[ws.ID])
+		for idx, spec := range specs {
+			var value strings.Builder
+			if err := templates[idx].Execute(&value, vc); err != nil {
+				return fmt.Errorf("variable %s for workspace %s: %w", spec.Key, ws.Name, err)
+			}
+			spec.Value = value.String()
+
+			change := variableChange{ws: ws, spec: spec}
+			for _, v := range existing {
+				if v.Key == spec.Key && string(v.Category) == spec.Category {
+					change.existing = v
+				}
+			}
+
+			switch {
+			case change.existing == nil:
+				slog.Info("will create", "workspace", ws.Name, "key", spec.Key, "value", displayValue(spec))
+			case change.existing.Sensitive && !spec.Sensitive:
+				// The API refuses to reveal a sensitive Variable, it has to be deleted and created again
+				slog.Warn("skipping, a sensitive variable can't be made non-sensitive, delete it to recreate it", "workspace", ws.Name, "key", spec.Key)
+				continue
+			case !change.existing.Sensitive && !spec.Sensitive && change.existing.Value == spec.Value &&
+				change.existing.HCL == spec.HCL && (spec.Description == "" || change.existing.Description == spec.Description):
+				slog.Info("unchanged", "workspace", ws.Name, "key", spec.Key)
+				continue
+			default:
+				slog.Info("will update", "workspace", ws.Name, "key", spec.Key, "value", displayValue(spec))
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	if confirm(len(changes), assume) {
+		for _, change := range changes {
+			if err := c.setVariable(ctx, change); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Every VariableSpec needs a key, and defaults to a Terraform Variable
+func normalizeVariableSpecs(specs []VariableSpec) error {
+	for idx, spec := range specs {
+		if spec.Key == "" {
+			return fmt.Errorf("variable %d has no key", idx)
+		}
+		if spec.Category == "" {
+			specs[idx].Category = string(tfe.CategoryTerraform)
+		} else if spec.Category != string(tfe.CategoryTerraform) && spec.Category != string(tfe.CategoryEnv) {
+			return fmt.Errorf("variable %s has unsupported category %q, expected terraform or env", spec.Key, spec.Category)
+		}
+	}
+	return nil
+}
+
+func (c *Client) setVariable(ctx context.Context, change variableChange) error {
+	if err := c.pauser.wait(ctx); err != nil {
+		return err
+	}
+
+	spec := change.spec
+	category := tfe.CategoryType(spec.Category)
+
+	var description *string
+	if spec.Description != "" {
+		description = tfe.String(spec.Description)
+	}
+
+	if change.existing == nil {
+		slog.Info("creating", "workspace", change.ws.Name, "key", spec.Key)
+		_, err := c.Variables.Create(ctx, change.ws.ID, tfe.VariableCreateOptions{
+			Key:         tfe.String(spec.Key),
+			Value:       tfe.String(spec.Value),
+			Description: description,
+			Category:    &category,
+			HCL:         tfe.Bool(spec.HCL),
+			Sensitive:   tfe.Bool(spec.Sensitive),
+		})
+		if err != nil {
+			return c.tolerate(change.ws.Name, err)
+		}
+	} else {
+		slog.Info("updating", "workspace", change.ws.Name, "key", spec.Key)
+		_, err := c.Variables.Update(ctx, change.ws.ID, change.existing.ID, tfe.VariableUpdateOptions{
+			Value:       tfe.String(spec.Value),
+			Description: description,
+			HCL:         tfe.Bool(spec.HCL),
+			Sensitive:   tfe.Bool(spec.Sensitive),
+		})
+		if err != nil {
+			return c.tolerate(change.ws.Name, err)
+		}
+	}
+
+	c.acted(ctx, "var-set", change.ws, nil)
+	return nil
+}
+
+func newVariableContext(ws *tfe.Workspace, project string) VariableContext {
+	vc := VariableContext{
+		ID:      ws.ID,
+		Name:    ws.Name,
+		Project: project,
+		Tags:    ws.TagNames,
+		Tag:     map[string]string{},
+	}
+	if ws.Organization != nil {
+		vc.Organization = ws.Organization.Name
+	}
+	for _, tag := range ws.TagNames {
+		if k, v, ok := strings.Cut(tag, ":"); ok {
+			vc.Tag[k] = v
+		}
+	}
+	return vc
+}
+
+// Never log the value of a sensitive Variable
+func displayValue(spec VariableSpec) string {
+	if spec.Sensitive {
+		return "(sensitive)"
+	}
+	return spec.Value
+}
+
+func (c *Client) getVariables(ctx context.Context, workspaceID string) ([]*tfe.Variable, error) {
+	var variables []*tfe.Variable
+
+	n := 0
+	for {
+		opts := &tfe.VariableListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+		}
+
+		varList, err := c.Variables.List(ctx, workspaceID, opts)
+		if err != nil {
+			return variables, err
+		}
+
+		variables = append(variables, varList.Items...)
+
+		if varList.NextPage > n {
+			n = varList.NextPage
+		} else {
+			return variables, nil
+		}
+	}
+}
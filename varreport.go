@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// The distinct values of one Variable across the fleet, to find inconsistently configured Workspaces
+type VariableReport struct {
+	Organization string                `json:"organization"`
+	Search       string                `json:"search,omitempty"`
+	Key          string                `json:"key"`
+	Workspaces   int                   `json:"workspaces"`
+	Values       []*VariableValueUsage `json:"values"`
+	// Workspaces without the Variable
+	Missing []string `json:"missing"`
+}
+
+type VariableValueUsage struct {
+	// Sensitive values are hashed, or "(sensitive)" when the API doesn't return them
+	Value      string   `json:"value"`
+	Category   string   `json:"category"`
+	HCL        bool     `json:"hcl"`
+	Sensitive  bool     `json:"sensitive"`
+	Count      int      `json:"count"`
+	Workspaces []string `json:"workspaces"`
+}
+
+// Report the distinct values of the Variable across the Workspace(s), most common first
+func (c *Client) VarReport(ctx context.Context, org, search, key, reportFile string) error {
+	if key == "" {
+		return fmt.Errorf("-var-key is required for var-report")
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	report := &VariableReport{Organization: org, Search: search, Key: key, Workspaces: len(workspaces), Missing: []string{}}
+	usages := map[string]*VariableValueUsage{}
+	for _, ws := range workspaces {
+		variables, err := c.getVariables(ctx, ws.ID)
+		if err != nil {
+			if err := c.tolerate(ws.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		found := false
+		for _, v := range variables {
+			if v.Key != key {
+				continue
+			}
+			found = true
+
+			value := reportedValue(v.Value, v.Sensitive)
+			id := fmt.Sprintf("%s\x00%s\x00%t\x00%t", value, v.Category, v.HCL, v.Sensitive)
+			usage, ok := usages[id]
+			if !ok {
+				usage = &VariableValueUsage{Value: value, Category: string(v.Category), HCL: v.HCL, Sensitive: v.Sensitive}
+				usages[id] = usage
+				report.Values = append(report.Values, usage)
+			}
+			usage.Count++
+			usage.Workspaces = append(usage.Workspaces, ws.Name)
+		}
+		if !found {
+			report.Missing = append(report.Missing, ws.Name)
+		}
+	}
+
+	sort.SliceStable(report.Values, func(i, j int) bool {
+		return report.Values[i].Count > report.Values[j].Count
+	})
+
+	slog.Info(fmt.Sprintf("Found %d distinct value(s) of %s across %d Workspace(s), missing from %d", len(report.Values), key, len(workspaces), len(report.Missing)))
+	return c.writeJSONReport(reportFile, report)
+}
+
+// Values of sensitive Variables are never reported, only enough of a hash to tell them apart
+func reportedValue(value string, sensitive bool) string {
+	if !sensitive {
+		return value
+	}
+	if value == "" {
+		return "(sensitive)"
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
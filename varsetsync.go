@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"gopkg.in/yaml.v3"
+)
+
+// A Variable to create, update or delete in a Variable Set
+type varSetChange struct {
+	spec     *VariableSpec
+	existing *tfe.VariableSetVariable
+}
+
+// Make the named Variable Set hold exactly the Variables in a JSON or YAML file of VariableSpecs: missing ones are
+// created, different ones updated and ones not in the file deleted. Values are used as they are, not as templates
+func (c *Client) VarSetSync(ctx context.Context, org string, assume bool, name, varFile string) error {
+	if name == "" || varFile == "" {
+		return fmt.Errorf("-variable-set and -var-file are required for varset-sync")
+	}
+
+	b, err := os.ReadFile(varFile)
+	if err != nil {
+		return err
+	}
+	var specs []VariableSpec
+	switch filepath.Ext(varFile) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &specs)
+	default:
+		err = json.Unmarshal(b, &specs)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", varFile, err)
+	}
+	if err := normalizeVariableSpecs(specs); err != nil {
+		return fmt.Errorf("%s: %w", varFile, err)
+	}
+
+	varSets, err := c.getVariableSets(ctx, org)
+	if err != nil {
+		return err
+	}
+	var vs *tfe.VariableSet
+	for _, candidate := range varSets {
+		if candidate.Name == name {
+			vs = candidate
+		}
+	}
+	if vs == nil {
+		return fmt.Errorf("variable set %q not found in %s", name, org)
+	}
+	if err := c.varSetAllowed(ctx, org, vs); err != nil {
+		return err
+	}
+
+	existing := map[variableID]*tfe.VariableSetVariable{}
+	for _, v := range vs.Variables {
+		existing[variableID{v.Key, string(v.Category)}] = v
+	}
+
+	var changes []varSetChange
+	wanted := map[variableID]bool{}
+	for idx := range specs {
+		spec := &specs[idx]
+		id := variableID{spec.Key, spec.Category}
+		if wanted[id] {
+			return fmt.Errorf("%s: variable %s is listed more than once", varFile, spec.Key)
+		}
+		wanted[id] = true
+
+		v := existing[id]
+		switch {
+		case v == nil:
+			slog.Info("will create", "variableSet", name, "key", spec.Key, "value", displayValue(*spec))
+		case v.Sensitive && !spec.Sensitive:
+			// The API refuses to reveal a sensitive Variable, it has to be deleted and created again
+			slog.Warn("skipping, a sensitive variable can't be made non-sensitive, delete it to recreate it", "variableSet", name, "key", spec.Key)
+			continue
+		case !v.Sensitive && !spec.Sensitive && v.Value == spec.Value &&
+			v.HCL == spec.HCL && (spec.Description == "" || v.Description == spec.Description):
+			slog.Info("unchanged", "variableSet", name, "key", spec.Key)
+			continue
+		default:
+			slog.Info("will update", "variableSet", name, "key", spec.Key, "value", displayValue(*spec))
+		}
+		changes = append(changes, varSetChange{spec: spec, existing: v})
+	}
+	for _, v := range vs.Variables {
+		if !wanted[variableID{v.Key, string(v.Category)}] {
+			slog.Info("will delete", "variableSet", name, "key", v.Key, "category", v.Category)
+			changes = append(changes, varSetChange{existing: v})
+		}
+	}
+
+	if confirm(len(changes), assume) {
+		// The change is recorded, hooked and counted against the Variable Set, standing in for a Workspace
+		target := &tfe.Workspace{ID: vs.ID, Name: vs.Name}
+		for _, change := range changes {
+			if err := c.pauser.wait(ctx); err != nil {
+				return err
+			}
+			if err := c.syncVarSetVariable(ctx, vs, change); err != nil {
+				if err := c.tolerate(name, err); err != nil {
+					return err
+				}
+				continue
+			}
+			c.acted(ctx, "varset-sync", target, nil)
+		}
+	}
+
+	return nil
+}
+
+// A Variable Set is shared, so it's only synced if no rule denies the action on any Workspace it applies to
+func (c *Client) varSetAllowed(ctx context.Context, org string, vs *tfe.VariableSet) error {
+	if c.rules == nil {
+		return nil
+	}
+
+	workspaces := vs.Workspaces
+	if vs.Global {
+		workspaces = nil
+		n := 0
+		for {
+			wsList, err := c.Workspaces.List(ctx, org, &tfe.WorkspaceListOptions{ListOptions: tfe.ListOptions{PageNumber: n}})
+			if err != nil {
+				return err
+			}
+			workspaces = append(workspaces, wsList.Items...)
+			if wsList.NextPage > n {
+				n = wsList.NextPage
+			} else {
+				break
+			}
+		}
+	}
+
+	now := time.Now()
+	for _, ws := range workspaces {
+		if rule := c.rules.denies(c.action, ws, now); rule != "" {
+			return fmt.Errorf("variable set %q applies to workspace %s, which rule %q denies %s on", vs.Name, ws.Name, rule, c.action)
+		}
+	}
+	return nil
+}
+
+func (c *Client) syncVarSetVariable(ctx context.Context, vs *tfe.VariableSet, change varSetChange) error {
+	spec := change.spec
+	if spec == nil {
+		slog.Info("deleting", "variableSet", vs.Name, "key", change.existing.Key)
+		return c.VariableSetVariables.Delete(ctx, vs.ID, change.existing.ID)
+	}
+
+	var description *string
+	if spec.Description != "" {
+		description = tfe.String(spec.Description)
+	}
+
+	if change.existing == nil {
+		slog.Info("creating", "variableSet", vs.Name, "key", spec.Key)
+		category := tfe.CategoryType(spec.Category)
+		_, err := c.VariableSetVariables.Create(ctx, vs.ID, &tfe.VariableSetVariableCreateOptions{
+			Key:         tfe.String(spec.Key),
+			Value:       tfe.String(spec.Value),
+			Description: description,
+			Category:    &category,
+			HCL:         tfe.Bool(spec.HCL),
+			Sensitive:   tfe.Bool(spec.Sensitive),
+		})
+		return err
+	}
+
+	slog.Info("updating", "variableSet", vs.Name, "key", spec.Key)
+	_, err := c.VariableSetVariables.Update(ctx, vs.ID, change.existing.ID, &tfe.VariableSetVariableUpdateOptions{
+		Value:       tfe.String(spec.Value),
+		Description: description,
+		HCL:         tfe.Bool(spec.HCL),
+		Sensitive:   tfe.Bool(spec.Sensitive),
+	})
+	return err
+}
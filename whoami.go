@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+// Which permission each action needs, checked against every matching Workspace
+var CAPABILITIES = []struct {
+	actions    string
+	permission string
+	allowed    func(ws *tfe.Workspace) bool
+}{
+	{"run,replan,apply", "can-queue-run", func(ws *tfe.Workspace) bool { return ws.Permissions.CanQueueRun }},
+	{"confirm,apply,cleanup", "can-apply", func(ws *tfe.Workspace) bool { return runPermissions(ws).CanApply }},
+	{"cancel,cleanup,expire", "can-cancel", func(ws *tfe.Workspace) bool { return runPermissions(ws).CanCancel }},
+	{"discard,cleanup,expire,supersede,replan", "can-discard", func(ws *tfe.Workspace) bool { return runPermissions(ws).CanDiscard }},
+	{"var-set,var-import,sensitive-audit", "can-update-variable", func(ws *tfe.Workspace) bool { return ws.Permissions.CanUpdateVariable }},
+	{"maintenance,archive,unarchive", "can-lock,can-unlock", func(ws *tfe.Workspace) bool { return ws.Permissions.CanLock && ws.Permissions.CanUnlock }},
+	{"settings,auto-apply-run-trigger,auto-destroy,align-defaults,migrate-remote,rewire-vcs,retarget-branch,tag-audit,archive,unarchive,mute,unmute", "can-update", func(ws *tfe.Workspace) bool { return ws.Permissions.CanUpdate }},
+}
+
+// The current Run's permissions, none for Workspaces which have never had a Run
+func runPermissions(ws *tfe.Workspace) *tfe.RunPermissions {
+	if ws.CurrentRun == nil || ws.CurrentRun.Permissions == nil {
+		return &tfe.RunPermissions{}
+	}
+	return ws.CurrentRun.Permissions
+}
+
+// Report who the token belongs to, its Organizations, and what it can do on the Workspace(s)
+func (c *Client) Whoami(ctx context.Context, org, search string) error {
+	user, err := c.Users.ReadCurrent(ctx)
+	if err != nil {
+		return err
+	}
+	if c.tokenType == "" {
+		c.tokenType = tokenType(user)
+	}
+	slog.Info("authenticated", "username", user.Username, "tokenType", c.tokenType, "email", user.Email)
+
+	if scopedToken(c.tokenType) {
+		slog.Info("skipping organizations, team and organization tokens belong to one", "org", org)
+	} else if orgs, err := c.getOrganizations(ctx); err != nil {
+		slog.Warn("unable to list organizations", "error", err)
+	} else {
+		for _, o := range orgs {
+			slog.Info("organization", "name", o.Name)
+		}
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	for _, capability := range CAPABILITIES {
+		allowed := 0
+		for _, ws := range workspaces {
+			if capability.allowed(ws) {
+				allowed++
+			}
+		}
+		slog.Info("capability", "actions", capability.actions, "permission", capability.permission, "allowed", allowed, "workspaces", len(workspaces))
+	}
+
+	return nil
+}
+
+// Report, per Workspace, which of the permissions the action needs the token has, without attempting it
+func (c *Client) SimulatePermissions(ctx context.Context, org, search, action string) error {
+	var needed []int
+	for idx, capability := range CAPABILITIES {
+		if slices.Contains(strings.Split(capability.actions, ","), action) {
+			needed = append(needed, idx)
+		}
+	}
+	if len(needed) == 0 {
+		return fmt.Errorf("action %s needs no workspace permissions to simulate", action)
+	}
+
+	workspaces, err := c.getWorkspaces(ctx, org, search)
+	if err != nil {
+		return err
+	}
+
+	permitted := 0
+	for _, ws := range workspaces {
+		var allowed, denied []string
+		for _, idx := range needed {
+			if CAPABILITIES[idx].allowed(ws) {
+				allowed = append(allowed, CAPABILITIES[idx].permission)
+			} else {
+				denied = append(denied, CAPABILITIES[idx].permission)
+			}
+		}
+
+		if len(denied) == 0 {
+			slog.Info("permitted", "workspace", ws.Name, "action", action, "allowed", strings.Join(allowed, ","))
+			permitted++
+		} else {
+			slog.Warn("not permitted", "workspace", ws.Name, "action", action, "allowed", strings.Join(allowed, ","), "denied", strings.Join(denied, ","))
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Permitted on %d of %d Workspace(s)", permitted, len(workspaces)))
+	return nil
+}
+
+// Organization and team tokens authenticate as service accounts with well known username prefixes
+func tokenType(user *tfe.User) string {
+	switch {
+	case !user.IsServiceAccount:
+		return TokenUser
+	case strings.HasPrefix(user.Username, "api-org-"):
+		return TokenOrganization
+	case strings.HasPrefix(user.Username, "api-team"):
+		return TokenTeam
+	}
+	return TokenServiceAccount
+}
+
+func (c *Client) getOrganizations(ctx context.Context) ([]*tfe.Organization, error) {
+	var orgs []*tfe.Organization
+
+	n := 0
+	for {
+		opts := &tfe.OrganizationListOptions{
+			ListOptions: tfe.ListOptions{
+				PageNumber: n,
+			},
+		}
+
+		orgList, err := c.Organizations.List(ctx, opts)
+		if err != nil {
+			return orgs, err
+		}
+
+		orgs = append(orgs, orgList.Items...)
+
+		if orgList.NextPage > n {
+			n = orgList.NextPage
+		} else {
+			return orgs, nil
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	tfe "github.com/hashicorp/go-tfe"
+	"golang.org/x/exp/slices"
+)
+
+func TestTokenType(t *testing.T) {
+	tests := []struct {
+		user *tfe.User
+		want string
+	}{
+		{&tfe.User{Username: "jdoe"}, TokenUser},
+		{&tfe.User{Username: "api-org-myOrg-abc123", IsServiceAccount: true}, TokenOrganization},
+		{&tfe.User{Username: "api-team_abc123", IsServiceAccount: true}, TokenTeam},
+		{&tfe.User{Username: "api-audit-trails", IsServiceAccount: true}, TokenServiceAccount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.user.Username, func(t *testing.T) {
+			got := tokenType(tt.user)
+			if got != tt.want {
+				t.Errorf("tokenType() = %q, want %q", got, tt.want)
+			}
+			if !slices.Contains(TOKEN_TYPES, got) {
+				t.Errorf("tokenType() = %q, which -token-type refuses", got)
+			}
+		})
+	}
+}